@@ -10,10 +10,13 @@ import (
 	"time"
 
 	"github.com/open-move/intercord/internal/api"
+	"github.com/open-move/intercord/internal/authz"
 	"github.com/open-move/intercord/internal/config"
 	"github.com/open-move/intercord/internal/database"
+	"github.com/open-move/intercord/internal/jobs"
 	"github.com/open-move/intercord/internal/middleware"
 	"github.com/open-move/intercord/internal/services"
+	"github.com/open-move/intercord/internal/sui"
 )
 
 func main() {
@@ -32,29 +35,77 @@ func main() {
 		baseURL = "http://localhost:" + cfg.Server.Port
 	}
 
-	emailService := services.NewEmailService(&cfg.Email)
-	userService := services.NewUserService(db, &cfg.JWT, emailService)
-	teamService := services.NewTeamService(db, emailService)
-	subscriptionService := services.NewSubscriptionService(db, teamService)
-	channelService := services.NewChannelService(db, teamService)
-	notificationService := services.NewNotificationService(db, teamService)
+	var attemptStore services.AttemptStore
+	switch cfg.Throttle.Store {
+	case "postgres":
+		attemptStore = services.NewPostgresAttemptStore(db)
+	default:
+		attemptStore = services.NewMemoryAttemptStore()
+	}
 
-	jwtMiddleware := middleware.NewJWTAuthMiddleware(&cfg.JWT)
+	emailService := services.NewEmailService(&cfg.Email)
+	authThrottle := services.NewAuthThrottle(attemptStore, &cfg.Throttle)
+	sessionService := services.NewSessionService(db, &cfg.JWT)
+	auditService := services.NewAuditService(db)
+	quotaService := services.NewQuotaService(db)
+	teamService := services.NewTeamService(db, &cfg.Auth, &cfg.Teams, cfg.JWT.Secret, emailService, auditService, quotaService)
+	userService := services.NewUserService(db, &cfg.JWT, &cfg.Auth, emailService, sessionService, teamService, authThrottle, &cfg.Throttle)
+	exportService := services.NewExportService(db, &cfg.Export, cfg.JWT.Secret, emailService)
+	suiClient := sui.NewClient(&cfg.Sui)
+	eventTypeService := services.NewEventTypeService(suiClient, &cfg.Sui)
+	coinService := services.NewCoinService(suiClient)
+	packageService := services.NewPackageService(suiClient)
+	systemService := services.NewSystemService(suiClient)
+	subscriptionService := services.NewSubscriptionService(db, auditService, quotaService, eventTypeService, coinService, packageService, systemService)
+	channelService := services.NewChannelService(db, auditService, quotaService)
+	notificationService := services.NewNotificationService(db, quotaService)
+	trashService := services.NewTrashService(db, &cfg.Trash, auditService, quotaService)
+	ingestionService := services.NewIngestionService(db, suiClient, &cfg.Sui, quotaService)
+	authorizer := authz.NewAuthorizer(teamService)
+
+	jwtMiddleware := middleware.NewJWTAuthMiddleware(&cfg.JWT, sessionService)
+	verifiedMiddleware := middleware.NewVerifiedEmailMiddleware(userService)
+	authorizationMiddleware := middleware.NewAuthorizationMiddleware(authorizer, teamService, subscriptionService, channelService, notificationService)
 
 	authHandler := api.NewAuthHandler(userService, baseURL)
-	teamHandler := api.NewTeamHandler(teamService, baseURL)
+	userHandler := api.NewUserHandler(userService, sessionService, baseURL)
+	exportHandler := api.NewExportHandler(exportService, baseURL)
+	teamHandler := api.NewTeamHandler(teamService, auditService, quotaService, baseURL)
 	subscriptionHandler := api.NewSubscriptionHandler(subscriptionService)
 	channelHandler := api.NewChannelHandler(channelService)
 	notificationHandler := api.NewNotificationHandler(notificationService)
+	trashHandler := api.NewTrashHandler(trashService)
 
-	router := api.SetupRouter(
+	router, err := api.SetupRouter(
 		authHandler,
+		userHandler,
+		exportHandler,
 		teamHandler,
 		subscriptionHandler,
 		channelHandler,
 		notificationHandler,
+		trashHandler,
 		jwtMiddleware,
+		verifiedMiddleware,
+		authorizationMiddleware,
+		cfg.Server.TrustedProxies,
 	)
+	if err != nil {
+		log.Fatalf("Failed to set up router: %v", err)
+	}
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	jobs.Every(jobsCtx, "purge-expired-tokens", cfg.Auth.TokenCleanupInterval, userService.PurgeExpiredTokens)
+	jobs.Every(jobsCtx, "purge-expired-sessions", cfg.Auth.TokenCleanupInterval, sessionService.PurgeExpired)
+	jobs.Every(jobsCtx, "purge-expired-exports", cfg.Auth.TokenCleanupInterval, exportService.PurgeExpired)
+	jobs.Every(jobsCtx, "expire-team-invitations", cfg.Auth.TokenCleanupInterval, teamService.ExpireInvitations)
+	jobs.Every(jobsCtx, "purge-trash", cfg.Trash.PurgeInterval, trashService.Purge)
+	jobs.Every(jobsCtx, "purge-expired-notifications", cfg.Auth.TokenCleanupInterval, quotaService.PurgeExpiredNotifications)
+	jobs.Every(jobsCtx, "ingest-events", cfg.Sui.PollInterval, ingestionService.PollEvents)
+	jobs.Every(jobsCtx, "ingest-transactions", cfg.Sui.PollInterval, ingestionService.PollTransactions)
+	jobs.Every(jobsCtx, "ingest-system", cfg.Sui.PollInterval, ingestionService.PollSystem)
 
 	server := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
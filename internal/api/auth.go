@@ -1,7 +1,9 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
@@ -43,7 +45,10 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	auth, err := h.userService.Login(c.Request.Context(), input)
+	auth, err := h.userService.Login(c.Request.Context(), input, c.ClientIP(), c.Request.UserAgent())
+	if respondThrottled(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
 		return
@@ -68,6 +73,44 @@ func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 	c.JSON(http.StatusOK, SuccessResponse{Message: "Email verified successfully"})
 }
 
+func (h *AuthHandler) ConfirmEmailChange(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Token is required"})
+		return
+	}
+
+	err := h.userService.ConfirmEmailChange(c.Request.Context(), token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Email changed successfully"})
+}
+
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input: " + err.Error()})
+		return
+	}
+
+	err := h.userService.ResendVerification(c.Request.Context(), input.Email, c.ClientIP(), h.baseURL)
+	if respondThrottled(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "If your email is registered and not yet verified, you will receive a new verification link"})
+}
+
 func (h *AuthHandler) RequestPasswordReset(c *gin.Context) {
 	var input struct {
 		Email string `json:"email" binding:"required,email"`
@@ -78,7 +121,10 @@ func (h *AuthHandler) RequestPasswordReset(c *gin.Context) {
 		return
 	}
 
-	err := h.userService.RequestPasswordReset(c.Request.Context(), input.Email, h.baseURL)
+	err := h.userService.RequestPasswordReset(c.Request.Context(), input.Email, c.ClientIP(), h.baseURL)
+	if respondThrottled(c, err) {
+		return
+	}
 	if err != nil {
 
 		c.JSON(http.StatusOK, SuccessResponse{Message: "If your email is registered, you will receive a password reset link"})
@@ -95,7 +141,10 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	err := h.userService.ResetPassword(c.Request.Context(), input)
+	err := h.userService.ResetPassword(c.Request.Context(), input, c.ClientIP())
+	if respondThrottled(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
@@ -103,3 +152,19 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 
 	c.JSON(http.StatusOK, SuccessResponse{Message: "Password reset successfully"})
 }
+
+func respondThrottled(c *gin.Context, err error) bool {
+	var throttleErr *services.ThrottleError
+	if !errors.As(err, &throttleErr) {
+		return false
+	}
+
+	retryAfter := int(throttleErr.RetryAfter.Seconds())
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: throttleErr.Error()})
+	return true
+}
@@ -0,0 +1,467 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/open-move/intercord/internal/authz"
+	"github.com/open-move/intercord/internal/middleware"
+	"github.com/open-move/intercord/internal/models"
+)
+
+const (
+	teamID         int64 = 1
+	subscriptionID int64 = 10
+	channelID      int64 = 20
+	notificationID int64 = 30
+
+	personalSubscriptionID int64 = 11
+	personalChannelID      int64 = 21
+	personalNotificationID int64 = 31
+)
+
+type role string
+
+const (
+	owner      role = "owner"
+	admin      role = "admin"
+	member     role = "member"
+	creator    role = "creator"
+	outsider   role = "outsider"
+	responder  role = "responder"
+	integrator role = "integrator"
+)
+
+var roles = map[role]int64{owner: 1, admin: 2, member: 3, outsider: 4, creator: 5, responder: 6, integrator: 7}
+
+var (
+	everyone            = []role{owner, admin, member, creator, outsider, responder, integrator}
+	teamMembers         = []role{owner, admin, member, creator, responder, integrator}
+	teamManagers        = []role{owner, admin}
+	teamOwner           = []role{owner}
+	managersOrCreator   = []role{owner, admin, creator}
+	subscriptionReaders = []role{owner, admin, member, creator, responder}
+	channelReaders      = []role{owner, admin, member, creator, integrator}
+	channelCreators     = []role{owner, admin, integrator}
+	channelEditors      = []role{owner, admin, creator, integrator}
+	redrivers           = []role{owner, admin, creator, responder}
+	trashReaders        = []role{owner, admin, member, creator}
+)
+
+var expectedAccess = map[string][]role{
+	"GET /me":                 everyone,
+	"PATCH /me":               everyone,
+	"DELETE /me":              everyone,
+	"POST /me/password":       everyone,
+	"POST /me/email":          everyone,
+	"GET /me/sessions":        everyone,
+	"DELETE /me/sessions/:id": everyone,
+	"POST /me/export":         everyone,
+	"GET /me/exports":         everyone,
+	"GET /me/invitations":     everyone,
+	"GET /me/trash":           everyone,
+
+	"POST /invitations/accept":  everyone,
+	"POST /invitations/decline": everyone,
+	"POST /invite-links/redeem": everyone,
+
+	"GET /teams":                 everyone,
+	"POST /teams":                everyone,
+	"GET /teams/:id":             teamMembers,
+	"DELETE /teams/:id":          teamOwner,
+	"POST /teams/:id/invite":     teamManagers,
+	"GET /teams/:id/invitations": teamManagers,
+	"POST /teams/:id/invitations/:invitation_id/resend": teamManagers,
+	"DELETE /teams/:id/invitations/:invitation_id":      teamManagers,
+	"POST /teams/:id/invite-links":                      teamManagers,
+	"GET /teams/:id/invite-links":                       teamManagers,
+	"GET /teams/:id/invite-links/:link_id/redemptions":  teamManagers,
+	"DELETE /teams/:id/invite-links/:link_id":           teamManagers,
+	"POST /teams/:id/join":                              everyone,
+	"PUT /teams/:id/join-policy":                        teamManagers,
+	"GET /teams/:id/join-requests":                      teamManagers,
+	"POST /teams/:id/join-requests/:request_id/approve": teamManagers,
+	"POST /teams/:id/join-requests/:request_id/reject":  teamManagers,
+	"POST /teams/:id/leave":                             everyone,
+	"GET /teams/:id/members":                            teamMembers,
+	"PUT /teams/:id/members/:user_id":                   teamManagers,
+	"DELETE /teams/:id/members/:user_id":                teamManagers,
+	"POST /teams/:id/transfer-ownership":                teamOwner,
+	"GET /teams/:id/roles":                              teamMembers,
+	"POST /teams/:id/roles":                             teamManagers,
+	"PUT /teams/:id/roles/:role_id":                     teamManagers,
+	"DELETE /teams/:id/roles/:role_id":                  teamManagers,
+	"GET /teams/:id/audit-log":                          teamManagers,
+	"POST /teams/:id/import":                            teamMembers,
+	"GET /teams/:id/usage":                              teamMembers,
+	"POST /teams/:id/restore":                           teamOwner,
+	"GET /teams/:id/trash":                              trashReaders,
+	"GET /teams/:id/subscriptions":                      subscriptionReaders,
+	"GET /teams/:id/channels":                           channelReaders,
+
+	"POST /subscriptions":             teamManagers,
+	"GET /subscriptions":              everyone,
+	"GET /subscriptions/:id":          subscriptionReaders,
+	"PUT /subscriptions/:id":          managersOrCreator,
+	"DELETE /subscriptions/:id":       managersOrCreator,
+	"POST /subscriptions/:id/restore": managersOrCreator,
+
+	"POST /channels":             channelCreators,
+	"GET /channels":              everyone,
+	"GET /channels/:id":          channelReaders,
+	"PUT /channels/:id":          channelEditors,
+	"DELETE /channels/:id":       channelEditors,
+	"POST /channels/:id/restore": channelEditors,
+	"POST /channels/subscribe":   managersOrCreator,
+	"POST /channels/unsubscribe": managersOrCreator,
+
+	"GET /notifications":              everyone,
+	"GET /notifications/:id":          subscriptionReaders,
+	"POST /notifications/:id/redrive": redrivers,
+}
+
+var publicEndpoints = map[string]bool{
+	"POST /auth/register":               true,
+	"POST /auth/login":                  true,
+	"GET /auth/verify-email":            true,
+	"POST /auth/resend-verification":    true,
+	"GET /auth/confirm-email-change":    true,
+	"POST /auth/request-reset-password": true,
+	"POST /auth/reset-password":         true,
+	"GET /exports/:id/download":         true,
+	"GET /invitations":                  true,
+}
+
+type fakeMemberships map[int64]*models.TeamMembership
+
+func (f fakeMemberships) GetMembership(ctx context.Context, team, user int64) (*models.TeamMembership, error) {
+	membership, ok := f[user]
+	if !ok || team != teamID {
+		return nil, errors.New("not found")
+	}
+	return membership, nil
+}
+
+// The fake lookups return the same resources whether or not they are in the
+// trash, so restore endpoints are checked against the same matrix.
+type fakeTeams map[int64]*models.Team
+
+func (f fakeTeams) GetTrashedByID(ctx context.Context, id int64) (*models.Team, error) {
+	if team, ok := f[id]; ok {
+		return team, nil
+	}
+	return nil, errors.New("not found")
+}
+
+type fakeSubscriptions map[int64]*models.Subscription
+
+func (f fakeSubscriptions) GetByID(ctx context.Context, id int64) (*models.Subscription, error) {
+	if subscription, ok := f[id]; ok {
+		return subscription, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (f fakeSubscriptions) GetTrashedByID(ctx context.Context, id int64) (*models.Subscription, error) {
+	return f.GetByID(ctx, id)
+}
+
+type fakeChannels map[int64]*models.Channel
+
+func (f fakeChannels) GetByID(ctx context.Context, id int64) (*models.Channel, error) {
+	if channel, ok := f[id]; ok {
+		return channel, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (f fakeChannels) GetTrashedByID(ctx context.Context, id int64) (*models.Channel, error) {
+	return f.GetByID(ctx, id)
+}
+
+type fakeNotifications map[int64]*models.Notification
+
+func (f fakeNotifications) GetByID(ctx context.Context, id int64) (*models.Notification, error) {
+	if notification, ok := f[id]; ok {
+		return notification, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func newTestAuthorization() *middleware.AuthorizationMiddleware {
+	team := teamID
+	responderRole := &models.TeamRoleDefinition{
+		Name:        "On-call responder",
+		Permissions: []string{string(authz.SubscriptionRead), string(authz.NotificationRead), string(authz.NotificationRedrive)},
+	}
+
+	integratorRole := &models.TeamRoleDefinition{
+		Name: "Integrator",
+		Permissions: []string{string(authz.ChannelCreate), string(authz.ChannelRead), string(authz.ChannelUpdate),
+			string(authz.ChannelDelete), string(authz.ChannelUse)},
+	}
+
+	memberships := fakeMemberships{
+		roles[owner]:      {Role: models.TeamRoleOwner},
+		roles[admin]:      {Role: models.TeamRoleAdmin},
+		roles[member]:     {Role: models.TeamRoleMember},
+		roles[creator]:    {Role: models.TeamRoleMember},
+		roles[responder]:  {Role: models.TeamRoleMember, CustomRole: responderRole},
+		roles[integrator]: {Role: models.TeamRoleMember, CustomRole: integratorRole},
+	}
+
+	subscriptions := fakeSubscriptions{
+		subscriptionID:         {ID: subscriptionID, UserID: roles[creator], TeamID: &team},
+		personalSubscriptionID: {ID: personalSubscriptionID, UserID: roles[outsider]},
+	}
+
+	channels := fakeChannels{
+		channelID:         {ID: channelID, UserID: roles[creator], TeamID: &team},
+		personalChannelID: {ID: personalChannelID, UserID: roles[outsider]},
+	}
+
+	notifications := fakeNotifications{
+		notificationID:         {ID: notificationID, SubscriptionID: subscriptionID},
+		personalNotificationID: {ID: personalNotificationID, SubscriptionID: personalSubscriptionID},
+	}
+
+	teams := fakeTeams{
+		teamID: {ID: teamID, OwnerID: roles[owner]},
+	}
+
+	return middleware.NewAuthorizationMiddleware(authz.NewAuthorizer(memberships), teams, subscriptions, channels, notifications)
+}
+
+func newTestEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	group := engine.Group("")
+	group.Use(func(c *gin.Context) {
+		userID, _ := strconv.ParseInt(c.GetHeader("X-User-ID"), 10, 64)
+		c.Set("userID", userID)
+	}, newTestAuthorization().Enforce(endpointPolicy))
+
+	for endpoint := range endpointPolicy {
+		method, path, _ := strings.Cut(endpoint, " ")
+		group.Handle(method, path, func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+	}
+
+	return engine
+}
+
+func requestPath(route string, personal bool) string {
+	ids := map[string]int64{"teams": teamID, "subscriptions": subscriptionID, "channels": channelID, "notifications": notificationID}
+	if personal {
+		ids = map[string]int64{"subscriptions": personalSubscriptionID, "channels": personalChannelID, "notifications": personalNotificationID}
+	}
+
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+
+		id := int64(1)
+		if segment == ":id" {
+			if resourceID, ok := ids[segments[i-1]]; ok {
+				id = resourceID
+			}
+		}
+		segments[i] = strconv.FormatInt(id, 10)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func requestBody(personal bool) string {
+	if personal {
+		return `{"subscription_id": 11, "channel_id": 21}`
+	}
+	return `{"team_id": 1, "subscription_id": 10, "channel_id": 20}`
+}
+
+func allowed(engine *gin.Engine, endpoint string, userID int64, personal bool) bool {
+	method, route, _ := strings.Cut(endpoint, " ")
+
+	req := httptest.NewRequest(method, requestPath(route, personal), strings.NewReader(requestBody(personal)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", strconv.FormatInt(userID, 10))
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+
+	return recorder.Code == http.StatusOK
+}
+
+func TestEveryRouteHasAuthorizationPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router, err := SetupRouter(&AuthHandler{}, &UserHandler{}, &ExportHandler{}, &TeamHandler{}, &SubscriptionHandler{},
+		&ChannelHandler{}, &NotificationHandler{}, &TrashHandler{}, &middleware.JWTAuthMiddleware{}, &middleware.VerifiedEmailMiddleware{},
+		&middleware.AuthorizationMiddleware{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	registered := map[string]bool{}
+	for _, route := range router.Routes() {
+		endpoint := route.Method + " " + route.Path
+		registered[endpoint] = true
+
+		if publicEndpoints[endpoint] {
+			continue
+		}
+
+		if _, ok := endpointPolicy[endpoint]; !ok {
+			t.Errorf("%s has no authorization policy", endpoint)
+		}
+
+		if _, ok := expectedAccess[endpoint]; !ok {
+			t.Errorf("%s is missing from the access matrix", endpoint)
+		}
+	}
+
+	for endpoint := range endpointPolicy {
+		if !registered[endpoint] {
+			t.Errorf("policy for %s does not match any route", endpoint)
+		}
+	}
+}
+
+func TestAuthorizationMatrix(t *testing.T) {
+	engine := newTestEngine()
+
+	endpoints := make([]string, 0, len(expectedAccess))
+	for endpoint := range expectedAccess {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	for _, endpoint := range endpoints {
+		expected := map[role]bool{}
+		for _, r := range expectedAccess[endpoint] {
+			expected[r] = true
+		}
+
+		for r, userID := range roles {
+			if got := allowed(engine, endpoint, userID, false); got != expected[r] {
+				t.Errorf("%s as %s: allowed=%v, want %v", endpoint, r, got, expected[r])
+			}
+		}
+	}
+}
+
+func TestPersonalResourcesOnlyAllowCreator(t *testing.T) {
+	engine := newTestEngine()
+
+	endpoints := []string{
+		"GET /subscriptions/:id",
+		"PUT /subscriptions/:id",
+		"DELETE /subscriptions/:id",
+		"POST /subscriptions/:id/restore",
+		"GET /channels/:id",
+		"PUT /channels/:id",
+		"DELETE /channels/:id",
+		"POST /channels/subscribe",
+		"POST /channels/unsubscribe",
+		"POST /channels/:id/restore",
+		"GET /notifications/:id",
+	}
+
+	for _, endpoint := range endpoints {
+		for r, userID := range roles {
+			want := r == outsider
+			if got := allowed(engine, endpoint, userID, true); got != want {
+				t.Errorf("%s on a personal resource as %s: allowed=%v, want %v", endpoint, r, got, want)
+			}
+		}
+	}
+}
+
+func TestUnknownResourcesAreNotFound(t *testing.T) {
+	engine := newTestEngine()
+
+	for _, path := range []string{"/subscriptions/99", "/channels/99", "/notifications/99"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-User-ID", strconv.FormatInt(roles[owner], 10))
+
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, req)
+
+		if recorder.Code != http.StatusNotFound {
+			t.Errorf("GET %s: status=%d, want %d", path, recorder.Code, http.StatusNotFound)
+		}
+	}
+}
+
+// GET /notifications is open to everyone but lists team notifications, so it
+// only gathers them from teams where the user may read notifications.
+func TestNotificationListOnlyCoversReadableTeams(t *testing.T) {
+	memberships := []models.TeamMembership{
+		{TeamID: 1, Role: models.TeamRoleOwner},
+		{TeamID: 2, Role: models.TeamRoleMember},
+		{TeamID: 3, Role: models.TeamRoleMember, CustomRole: &models.TeamRoleDefinition{
+			Name:        "On-call responder",
+			Permissions: []string{string(authz.NotificationRead)},
+		}},
+		{TeamID: 4, Role: models.TeamRoleMember, CustomRole: &models.TeamRoleDefinition{
+			Name:        "Integrator",
+			Permissions: []string{string(authz.ChannelRead), string(authz.ChannelUse)},
+		}},
+	}
+
+	got := authz.TeamsAllowing(memberships, authz.NotificationRead)
+	if want := []int64{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("teams listed: %v, want %v", got, want)
+	}
+}
+
+// Inviting someone, by email or through an invite link, hands them a role, so
+// the inviter needs every permission of that role.
+func TestInvitationsOnlyGrantHeldPermissions(t *testing.T) {
+	recruiter := &models.TeamMembership{Role: models.TeamRoleMember, CustomRole: &models.TeamRoleDefinition{
+		Name:        "Recruiter",
+		Permissions: []string{string(authz.TeamManageMembers)},
+	}}
+
+	readingRecruiter := &models.TeamMembership{Role: models.TeamRoleMember, CustomRole: &models.TeamRoleDefinition{
+		Name: "Reading recruiter",
+		Permissions: []string{string(authz.TeamManageMembers), string(authz.SubscriptionRead), string(authz.ChannelRead),
+			string(authz.NotificationRead)},
+	}}
+
+	tests := []struct {
+		name    string
+		granter *models.TeamMembership
+		role    models.TeamRole
+		want    bool
+	}{
+		{"owner invites admin", &models.TeamMembership{Role: models.TeamRoleOwner}, models.TeamRoleAdmin, true},
+		{"owner invites member", &models.TeamMembership{Role: models.TeamRoleOwner}, models.TeamRoleMember, true},
+		{"admin invites admin", &models.TeamMembership{Role: models.TeamRoleAdmin}, models.TeamRoleAdmin, true},
+		{"admin invites member", &models.TeamMembership{Role: models.TeamRoleAdmin}, models.TeamRoleMember, true},
+		{"recruiter invites admin", recruiter, models.TeamRoleAdmin, false},
+		{"recruiter invites member", recruiter, models.TeamRoleMember, false},
+		{"reading recruiter invites admin", readingRecruiter, models.TeamRoleAdmin, false},
+		{"reading recruiter invites member", readingRecruiter, models.TeamRoleMember, true},
+		{"admin invites owner", &models.TeamMembership{Role: models.TeamRoleAdmin}, models.TeamRoleOwner, false},
+	}
+
+	for _, tt := range tests {
+		if got := authz.CanGrant(tt.granter, tt.role); got != tt.want {
+			t.Errorf("%s: CanGrant=%v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
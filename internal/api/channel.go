@@ -29,7 +29,7 @@ func (h *ChannelHandler) CreateChannel(c *gin.Context) {
 	userID := c.GetInt64("userID")
 	channel, err := h.channelService.Create(c.Request.Context(), input, userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		c.JSON(errorStatus(err, http.StatusBadRequest), ErrorResponse{Error: err.Error()})
 		return
 	}
 
@@ -48,7 +48,7 @@ func (h *ChannelHandler) GetChannels(c *gin.Context) {
 }
 
 func (h *ChannelHandler) GetTeamChannels(c *gin.Context) {
-	teamIDStr := c.Param("team_id")
+	teamIDStr := c.Param("id")
 	teamID, err := strconv.ParseInt(teamIDStr, 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
@@ -120,7 +120,7 @@ func (h *ChannelHandler) DeleteChannel(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, SuccessResponse{Message: "Channel deleted successfully"})
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Channel moved to trash"})
 }
 
 func (h *ChannelHandler) SubscribeChannel(c *gin.Context) {
@@ -1,5 +1,13 @@
 package api
 
+import (
+	"errors"
+	"net/http"
+
+	"github.com/open-move/intercord/internal/authz"
+	"github.com/open-move/intercord/internal/services"
+)
+
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
@@ -14,3 +22,23 @@ type PagedResponse struct {
 	Limit      int         `json:"limit"`
 	Offset     int         `json:"offset"`
 }
+
+type CursorResponse struct {
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// errorStatus maps service errors that carry their own HTTP meaning and falls
+// back to the given status for everything else.
+func errorStatus(err error, fallback int) int {
+	var limitErr *services.LimitError
+	switch {
+	case errors.As(err, &limitErr):
+		return http.StatusPaymentRequired
+	case errors.Is(err, services.ErrNotificationQuotaExceeded):
+		return http.StatusTooManyRequests
+	case errors.Is(err, authz.ErrForbidden):
+		return http.StatusForbidden
+	}
+	return fallback
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/open-move/intercord/internal/services"
+)
+
+type ExportHandler struct {
+	exportService *services.ExportService
+	baseURL       string
+}
+
+func NewExportHandler(exportService *services.ExportService, baseURL string) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+		baseURL:       baseURL,
+	}
+}
+
+func (h *ExportHandler) RequestExport(c *gin.Context) {
+	userID := c.GetInt64("userID")
+	export, err := h.exportService.Request(c.Request.Context(), userID, h.baseURL)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, export)
+}
+
+func (h *ExportHandler) GetExports(c *gin.Context) {
+	userID := c.GetInt64("userID")
+	exports, err := h.exportService.List(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, exports)
+}
+
+func (h *ExportHandler) DownloadExport(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid export ID"})
+		return
+	}
+
+	export, err := h.exportService.Open(c.Request.Context(), id, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.FileAttachment(export.FilePath, fmt.Sprintf("intercord-export-%d.zip", export.ID))
+}
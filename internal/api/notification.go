@@ -57,8 +57,7 @@ func (h *NotificationHandler) GetNotification(c *gin.Context) {
 		return
 	}
 
-	userID := c.GetInt64("userID")
-	notification, err := h.notificationService.GetByID(c.Request.Context(), id, userID)
+	notification, err := h.notificationService.GetByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
 		return
@@ -66,3 +65,20 @@ func (h *NotificationHandler) GetNotification(c *gin.Context) {
 
 	c.JSON(http.StatusOK, notification)
 }
+
+func (h *NotificationHandler) RedriveNotification(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid notification ID"})
+		return
+	}
+
+	notification, err := h.notificationService.Redrive(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"github.com/open-move/intercord/internal/authz"
+	"github.com/open-move/intercord/internal/middleware"
+)
+
+func can(action authz.Action, source middleware.ResourceSource) []middleware.Permission {
+	return []middleware.Permission{{Action: action, Source: source}}
+}
+
+var self = []middleware.Permission{}
+
+var endpointPolicy = middleware.Policy{
+	"GET /me":                 self,
+	"PATCH /me":               self,
+	"DELETE /me":              self,
+	"POST /me/password":       self,
+	"POST /me/email":          self,
+	"GET /me/sessions":        self,
+	"DELETE /me/sessions/:id": self,
+	"POST /me/export":         self,
+	"GET /me/exports":         self,
+	"GET /me/invitations":     self,
+	"GET /me/trash":           self,
+
+	"POST /invitations/accept":  self,
+	"POST /invitations/decline": self,
+	"POST /invite-links/redeem": self,
+
+	"GET /teams":                 self,
+	"POST /teams":                self,
+	"GET /teams/:id":             can(authz.TeamRead, middleware.TeamParam),
+	"DELETE /teams/:id":          can(authz.TeamDelete, middleware.TeamParam),
+	"POST /teams/:id/invite":     can(authz.TeamManageMembers, middleware.TeamParam),
+	"GET /teams/:id/invitations": can(authz.TeamManageMembers, middleware.TeamParam),
+	"POST /teams/:id/invitations/:invitation_id/resend": can(authz.TeamManageMembers, middleware.TeamParam),
+	"DELETE /teams/:id/invitations/:invitation_id":      can(authz.TeamManageMembers, middleware.TeamParam),
+	"POST /teams/:id/invite-links":                      can(authz.TeamManageMembers, middleware.TeamParam),
+	"GET /teams/:id/invite-links":                       can(authz.TeamManageMembers, middleware.TeamParam),
+	"GET /teams/:id/invite-links/:link_id/redemptions":  can(authz.TeamManageMembers, middleware.TeamParam),
+	"DELETE /teams/:id/invite-links/:link_id":           can(authz.TeamManageMembers, middleware.TeamParam),
+	"POST /teams/:id/join":                              self,
+	"PUT /teams/:id/join-policy":                        can(authz.TeamUpdate, middleware.TeamParam),
+	"GET /teams/:id/join-requests":                      can(authz.TeamManageMembers, middleware.TeamParam),
+	"POST /teams/:id/join-requests/:request_id/approve": can(authz.TeamManageMembers, middleware.TeamParam),
+	"POST /teams/:id/join-requests/:request_id/reject":  can(authz.TeamManageMembers, middleware.TeamParam),
+	"POST /teams/:id/leave":                             self,
+	"GET /teams/:id/members":                            can(authz.TeamRead, middleware.TeamParam),
+	"PUT /teams/:id/members/:user_id":                   can(authz.TeamManageMembers, middleware.TeamParam),
+	"DELETE /teams/:id/members/:user_id":                can(authz.TeamManageMembers, middleware.TeamParam),
+	"POST /teams/:id/transfer-ownership":                can(authz.TeamTransferOwnership, middleware.TeamParam),
+	"GET /teams/:id/roles":                              can(authz.TeamRead, middleware.TeamParam),
+	"POST /teams/:id/roles":                             can(authz.TeamManageRoles, middleware.TeamParam),
+	"PUT /teams/:id/roles/:role_id":                     can(authz.TeamManageRoles, middleware.TeamParam),
+	"DELETE /teams/:id/roles/:role_id":                  can(authz.TeamManageRoles, middleware.TeamParam),
+	"GET /teams/:id/audit-log":                          can(authz.TeamReadAuditLog, middleware.TeamParam),
+	// The resources to move are listed in the body, so the service checks
+	// ownership and subscription:create / channel:create for what is moved.
+	"POST /teams/:id/import":  can(authz.TeamRead, middleware.TeamParam),
+	"GET /teams/:id/usage":    can(authz.TeamRead, middleware.TeamParam),
+	"POST /teams/:id/restore": can(authz.TeamDelete, middleware.TrashedTeamParam),
+	"GET /teams/:id/trash": {
+		{Action: authz.SubscriptionRead, Source: middleware.TeamParam},
+		{Action: authz.ChannelRead, Source: middleware.TeamParam},
+	},
+	"GET /teams/:id/subscriptions": can(authz.SubscriptionRead, middleware.TeamParam),
+	"GET /teams/:id/channels":      can(authz.ChannelRead, middleware.TeamParam),
+
+	"POST /subscriptions":             can(authz.SubscriptionCreate, middleware.TeamBody),
+	"GET /subscriptions":              self,
+	"GET /subscriptions/:id":          can(authz.SubscriptionRead, middleware.SubscriptionParam),
+	"PUT /subscriptions/:id":          can(authz.SubscriptionUpdate, middleware.SubscriptionParam),
+	"DELETE /subscriptions/:id":       can(authz.SubscriptionDelete, middleware.SubscriptionParam),
+	"POST /subscriptions/:id/restore": can(authz.SubscriptionDelete, middleware.TrashedSubscriptionParam),
+
+	"POST /channels":             can(authz.ChannelCreate, middleware.TeamBody),
+	"GET /channels":              self,
+	"GET /channels/:id":          can(authz.ChannelRead, middleware.ChannelParam),
+	"PUT /channels/:id":          can(authz.ChannelUpdate, middleware.ChannelParam),
+	"DELETE /channels/:id":       can(authz.ChannelDelete, middleware.ChannelParam),
+	"POST /channels/:id/restore": can(authz.ChannelDelete, middleware.TrashedChannelParam),
+	"POST /channels/subscribe": {
+		{Action: authz.SubscriptionUpdate, Source: middleware.SubscriptionBody},
+		{Action: authz.ChannelUse, Source: middleware.ChannelBody},
+	},
+	"POST /channels/unsubscribe": can(authz.SubscriptionUpdate, middleware.SubscriptionBody),
+
+	"GET /notifications":              self,
+	"GET /notifications/:id":          can(authz.NotificationRead, middleware.NotificationParam),
+	"POST /notifications/:id/redrive": can(authz.NotificationRedrive, middleware.NotificationParam),
+}
@@ -8,59 +8,126 @@ import (
 
 func SetupRouter(
 	authHandler *AuthHandler,
+	userHandler *UserHandler,
+	exportHandler *ExportHandler,
 	teamHandler *TeamHandler,
 	subscriptionHandler *SubscriptionHandler,
 	channelHandler *ChannelHandler,
 	notificationHandler *NotificationHandler,
+	trashHandler *TrashHandler,
 	jwtMiddleware *middleware.JWTAuthMiddleware,
-) *gin.Engine {
+	verifiedMiddleware *middleware.VerifiedEmailMiddleware,
+	authorizationMiddleware *middleware.AuthorizationMiddleware,
+	trustedProxies []string,
+) (*gin.Engine, error) {
 	router := gin.Default()
 
+	// Without trusted proxies, ClientIP ignores X-Forwarded-For and friends so
+	// clients can't pick the IP that per-IP throttling and lockouts key on.
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
+
 	auth := router.Group("/auth")
 	{
 		auth.POST("/register", authHandler.Register)
 		auth.POST("/login", authHandler.Login)
 		auth.GET("/verify-email", authHandler.VerifyEmail)
+		auth.POST("/resend-verification", authHandler.ResendVerification)
+		auth.GET("/confirm-email-change", authHandler.ConfirmEmailChange)
 		auth.POST("/request-reset-password", authHandler.RequestPasswordReset)
 		auth.POST("/reset-password", authHandler.ResetPassword)
 	}
 
+	router.GET("/exports/:id/download", exportHandler.DownloadExport)
+	router.GET("/invitations", teamHandler.GetInvitation)
+
 	api := router.Group("")
-	api.Use(jwtMiddleware.AuthRequired())
+	api.Use(jwtMiddleware.AuthRequired(), authorizationMiddleware.Enforce(endpointPolicy))
 	{
+		verified := verifiedMiddleware.VerifiedRequired()
+
+		me := api.Group("/me")
+		{
+			me.GET("", userHandler.GetMe)
+			me.PATCH("", userHandler.UpdateMe)
+			me.DELETE("", userHandler.DeleteMe)
+			me.POST("/password", userHandler.ChangePassword)
+			me.POST("/email", userHandler.ChangeEmail)
+			me.GET("/sessions", userHandler.GetSessions)
+			me.DELETE("/sessions/:id", userHandler.RevokeSession)
+			me.POST("/export", exportHandler.RequestExport)
+			me.GET("/exports", exportHandler.GetExports)
+			me.GET("/invitations", teamHandler.GetMyInvitations)
+			me.GET("/trash", trashHandler.GetMyTrash)
+		}
+
+		invitations := api.Group("/invitations")
+		{
+			invitations.POST("/accept", verified, teamHandler.AcceptInvitation)
+			invitations.POST("/decline", teamHandler.DeclineInvitation)
+		}
+
+		api.POST("/invite-links/redeem", verified, teamHandler.RedeemInviteLink)
 
 		teams := api.Group("/teams")
 		{
 			teams.GET("", teamHandler.GetTeams)
-			teams.POST("", teamHandler.CreateTeam)
+			teams.POST("", verified, teamHandler.CreateTeam)
 			teams.GET("/:id", teamHandler.GetTeam)
 			teams.DELETE("/:id", teamHandler.DeleteTeam)
-			teams.POST("/:id/invite", teamHandler.InviteToTeam)
-			teams.POST("/:id/join", teamHandler.JoinTeam)
+			teams.POST("/:id/restore", trashHandler.RestoreTeam)
+			teams.GET("/:id/trash", trashHandler.GetTeamTrash)
+			teams.POST("/:id/import", teamHandler.ImportResources)
+			teams.GET("/:id/usage", teamHandler.GetUsage)
+			teams.POST("/:id/invite", verified, teamHandler.InviteToTeam)
+			teams.GET("/:id/invitations", teamHandler.GetTeamInvitations)
+			teams.POST("/:id/invitations/:invitation_id/resend", teamHandler.ResendInvitation)
+			teams.DELETE("/:id/invitations/:invitation_id", teamHandler.RevokeInvitation)
+			teams.POST("/:id/invite-links", verified, teamHandler.CreateInviteLink)
+			teams.GET("/:id/invite-links", teamHandler.GetInviteLinks)
+			teams.GET("/:id/invite-links/:link_id/redemptions", teamHandler.GetInviteLinkRedemptions)
+			teams.DELETE("/:id/invite-links/:link_id", teamHandler.RevokeInviteLink)
+			teams.POST("/:id/join", verified, teamHandler.JoinTeam)
+			teams.PUT("/:id/join-policy", teamHandler.UpdateJoinPolicy)
+			teams.GET("/:id/join-requests", teamHandler.GetJoinRequests)
+			teams.POST("/:id/join-requests/:request_id/approve", teamHandler.ApproveJoinRequest)
+			teams.POST("/:id/join-requests/:request_id/reject", teamHandler.RejectJoinRequest)
 			teams.POST("/:id/leave", teamHandler.LeaveTeam)
+			teams.GET("/:id/members", teamHandler.GetMembers)
+			teams.PUT("/:id/members/:user_id", teamHandler.UpdateMemberRole)
+			teams.DELETE("/:id/members/:user_id", teamHandler.RemoveMember)
+			teams.POST("/:id/transfer-ownership", teamHandler.TransferOwnership)
+			teams.GET("/:id/roles", teamHandler.GetRoles)
+			teams.POST("/:id/roles", teamHandler.CreateRole)
+			teams.PUT("/:id/roles/:role_id", teamHandler.UpdateRole)
+			teams.DELETE("/:id/roles/:role_id", teamHandler.DeleteRole)
+			teams.GET("/:id/audit-log", teamHandler.GetAuditLog)
 
-			teams.GET("/:team_id/subscriptions", subscriptionHandler.GetTeamSubscriptions)
+			teams.GET("/:id/subscriptions", subscriptionHandler.GetTeamSubscriptions)
 
-			teams.GET("/:team_id/channels", channelHandler.GetTeamChannels)
+			teams.GET("/:id/channels", channelHandler.GetTeamChannels)
 		}
 
 		subscriptions := api.Group("/subscriptions")
 		{
-			subscriptions.POST("", subscriptionHandler.CreateSubscription)
+			subscriptions.POST("", verified, subscriptionHandler.CreateSubscription)
 			subscriptions.GET("", subscriptionHandler.GetSubscriptions)
 			subscriptions.GET("/:id", subscriptionHandler.GetSubscription)
-			subscriptions.PUT("/:id", subscriptionHandler.UpdateSubscription)
+			subscriptions.PUT("/:id", verified, subscriptionHandler.UpdateSubscription)
 			subscriptions.DELETE("/:id", subscriptionHandler.DeleteSubscription)
+			subscriptions.POST("/:id/restore", trashHandler.RestoreSubscription)
 		}
 
 		channels := api.Group("/channels")
 		{
-			channels.POST("", channelHandler.CreateChannel)
+			channels.POST("", verified, channelHandler.CreateChannel)
 			channels.GET("", channelHandler.GetChannels)
 			channels.GET("/:id", channelHandler.GetChannel)
-			channels.PUT("/:id", channelHandler.UpdateChannel)
+			channels.PUT("/:id", verified, channelHandler.UpdateChannel)
 			channels.DELETE("/:id", channelHandler.DeleteChannel)
-			channels.POST("/subscribe", channelHandler.SubscribeChannel)
+			channels.POST("/:id/restore", trashHandler.RestoreChannel)
+			channels.POST("/subscribe", verified, channelHandler.SubscribeChannel)
 			channels.POST("/unsubscribe", channelHandler.UnsubscribeChannel)
 		}
 
@@ -68,8 +135,9 @@ func SetupRouter(
 		{
 			notifications.GET("", notificationHandler.GetNotifications)
 			notifications.GET("/:id", notificationHandler.GetNotification)
+			notifications.POST("/:id/redrive", notificationHandler.RedriveNotification)
 		}
 	}
 
-	return router
+	return router, nil
 }
@@ -29,7 +29,7 @@ func (h *SubscriptionHandler) CreateSubscription(c *gin.Context) {
 	userID := c.GetInt64("userID")
 	subscription, err := h.subscriptionService.Create(c.Request.Context(), input, userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		c.JSON(errorStatus(err, http.StatusBadRequest), ErrorResponse{Error: err.Error()})
 		return
 	}
 
@@ -48,7 +48,7 @@ func (h *SubscriptionHandler) GetSubscriptions(c *gin.Context) {
 }
 
 func (h *SubscriptionHandler) GetTeamSubscriptions(c *gin.Context) {
-	teamIDStr := c.Param("team_id")
+	teamIDStr := c.Param("id")
 	teamID, err := strconv.ParseInt(teamIDStr, 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
@@ -120,5 +120,5 @@ func (h *SubscriptionHandler) DeleteSubscription(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, SuccessResponse{Message: "Subscription deleted successfully"})
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Subscription moved to trash"})
 }
@@ -3,21 +3,28 @@ package api
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/open-move/intercord/internal/authz"
+	"github.com/open-move/intercord/internal/models"
 	"github.com/open-move/intercord/internal/services"
 )
 
 type TeamHandler struct {
-	teamService *services.TeamService
-	baseURL     string
+	teamService  *services.TeamService
+	auditService *services.AuditService
+	quotaService *services.QuotaService
+	baseURL      string
 }
 
-func NewTeamHandler(teamService *services.TeamService, baseURL string) *TeamHandler {
+func NewTeamHandler(teamService *services.TeamService, auditService *services.AuditService, quotaService *services.QuotaService, baseURL string) *TeamHandler {
 	return &TeamHandler{
-		teamService: teamService,
-		baseURL:     baseURL,
+		teamService:  teamService,
+		auditService: auditService,
+		quotaService: quotaService,
+		baseURL:      baseURL,
 	}
 }
 
@@ -82,13 +89,147 @@ func (h *TeamHandler) InviteToTeam(c *gin.Context) {
 
 	input.TeamID = id
 	userID := c.GetInt64("userID")
-	err = h.teamService.InviteToTeam(c.Request.Context(), input, userID, h.baseURL)
+	invitation, err := h.teamService.InviteToTeam(c.Request.Context(), input, userID, h.baseURL)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, SuccessResponse{Message: "Invitation sent"})
+	c.JSON(http.StatusCreated, invitation)
+}
+
+func (h *TeamHandler) GetTeamInvitations(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
+		return
+	}
+
+	invitations, err := h.teamService.GetTeamInvitations(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+func (h *TeamHandler) ResendInvitation(c *gin.Context) {
+	teamID, invitationID, ok := parseTeamInvitationIDs(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	err := h.teamService.ResendInvitation(c.Request.Context(), teamID, invitationID, userID, h.baseURL)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Invitation resent"})
+}
+
+func (h *TeamHandler) RevokeInvitation(c *gin.Context) {
+	teamID, invitationID, ok := parseTeamInvitationIDs(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	err := h.teamService.RevokeInvitation(c.Request.Context(), teamID, invitationID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Invitation revoked"})
+}
+
+func (h *TeamHandler) GetMyInvitations(c *gin.Context) {
+	userID := c.GetInt64("userID")
+	invitations, err := h.teamService.GetUserInvitations(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// GetInvitation shows the invitation behind the link in an invitation email.
+// It needs no login, since the invitee may not have an account yet.
+func (h *TeamHandler) GetInvitation(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Token is required"})
+		return
+	}
+
+	invitation, err := h.teamService.GetInvitation(c.Request.Context(), token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitation)
+}
+
+func (h *TeamHandler) AcceptInvitation(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input: " + err.Error()})
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	membership, err := h.teamService.AcceptInvitation(c.Request.Context(), input.Token, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, membership)
+}
+
+func (h *TeamHandler) DeclineInvitation(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input: " + err.Error()})
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	err := h.teamService.DeclineInvitation(c.Request.Context(), input.Token, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Invitation declined"})
+}
+
+func parseTeamInvitationIDs(c *gin.Context) (int64, int64, bool) {
+	teamID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
+		return 0, 0, false
+	}
+
+	invitationID, err := strconv.ParseInt(c.Param("invitation_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid invitation ID"})
+		return 0, 0, false
+	}
+
+	return teamID, invitationID, true
 }
 
 func (h *TeamHandler) JoinTeam(c *gin.Context) {
@@ -99,14 +240,99 @@ func (h *TeamHandler) JoinTeam(c *gin.Context) {
 		return
 	}
 
+	var input services.JoinTeamInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input: " + err.Error()})
+			return
+		}
+	}
+
+	userID := c.GetInt64("userID")
+	membership, request, err := h.teamService.JoinTeam(c.Request.Context(), id, userID, input, h.baseURL)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if request != nil {
+		c.JSON(http.StatusAccepted, request)
+		return
+	}
+
+	c.JSON(http.StatusOK, membership)
+}
+
+func (h *TeamHandler) UpdateJoinPolicy(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
+		return
+	}
+
+	var input services.UpdateJoinPolicyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input: " + err.Error()})
+		return
+	}
+
 	userID := c.GetInt64("userID")
-	err = h.teamService.JoinTeam(c.Request.Context(), id, userID)
+	team, err := h.teamService.UpdateJoinPolicy(c.Request.Context(), id, userID, input)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, SuccessResponse{Message: "Joined team successfully"})
+	c.JSON(http.StatusOK, team)
+}
+
+func (h *TeamHandler) GetJoinRequests(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
+		return
+	}
+
+	requests, err := h.teamService.GetJoinRequests(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, requests)
+}
+
+func (h *TeamHandler) ApproveJoinRequest(c *gin.Context) {
+	h.reviewJoinRequest(c, true)
+}
+
+func (h *TeamHandler) RejectJoinRequest(c *gin.Context) {
+	h.reviewJoinRequest(c, false)
+}
+
+func (h *TeamHandler) reviewJoinRequest(c *gin.Context, approve bool) {
+	teamID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
+		return
+	}
+
+	requestID, err := strconv.ParseInt(c.Param("request_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid join request ID"})
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	request, err := h.teamService.ReviewJoinRequest(c.Request.Context(), teamID, requestID, userID, approve)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
 }
 
 func (h *TeamHandler) LeaveTeam(c *gin.Context) {
@@ -142,5 +368,406 @@ func (h *TeamHandler) DeleteTeam(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, SuccessResponse{Message: "Team deleted successfully"})
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Team moved to trash"})
+}
+
+func (h *TeamHandler) CreateInviteLink(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
+		return
+	}
+
+	var input services.CreateInviteLinkInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input: " + err.Error()})
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	link, err := h.teamService.CreateInviteLink(c.Request.Context(), id, userID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, link)
+}
+
+func (h *TeamHandler) GetInviteLinks(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
+		return
+	}
+
+	links, err := h.teamService.GetInviteLinks(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+func (h *TeamHandler) GetInviteLinkRedemptions(c *gin.Context) {
+	teamID, linkID, ok := parseTeamInviteLinkIDs(c)
+	if !ok {
+		return
+	}
+
+	redemptions, err := h.teamService.GetInviteLinkRedemptions(c.Request.Context(), teamID, linkID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, redemptions)
+}
+
+func (h *TeamHandler) RevokeInviteLink(c *gin.Context) {
+	teamID, linkID, ok := parseTeamInviteLinkIDs(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	err := h.teamService.RevokeInviteLink(c.Request.Context(), teamID, linkID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Invite link revoked"})
+}
+
+func (h *TeamHandler) RedeemInviteLink(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input: " + err.Error()})
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	membership, err := h.teamService.RedeemInviteLink(c.Request.Context(), input.Token, userID, c.ClientIP())
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, membership)
+}
+
+func parseTeamInviteLinkIDs(c *gin.Context) (int64, int64, bool) {
+	teamID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
+		return 0, 0, false
+	}
+
+	linkID, err := strconv.ParseInt(c.Param("link_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid invite link ID"})
+		return 0, 0, false
+	}
+
+	return teamID, linkID, true
+}
+
+func (h *TeamHandler) GetMembers(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
+		return
+	}
+
+	members, err := h.teamService.GetMembers(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+func (h *TeamHandler) UpdateMemberRole(c *gin.Context) {
+	teamID, memberID, ok := parseTeamMemberIDs(c)
+	if !ok {
+		return
+	}
+
+	var input services.UpdateMemberRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input: " + err.Error()})
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	membership, err := h.teamService.UpdateMemberRole(c.Request.Context(), teamID, memberID, userID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, membership)
+}
+
+func (h *TeamHandler) RemoveMember(c *gin.Context) {
+	teamID, memberID, ok := parseTeamMemberIDs(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	err := h.teamService.RemoveMember(c.Request.Context(), teamID, memberID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Member removed successfully"})
+}
+
+func (h *TeamHandler) TransferOwnership(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
+		return
+	}
+
+	var input services.TransferOwnershipInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input: " + err.Error()})
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	team, err := h.teamService.TransferOwnership(c.Request.Context(), id, userID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+func parseTeamMemberIDs(c *gin.Context) (int64, int64, bool) {
+	teamID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
+		return 0, 0, false
+	}
+
+	memberID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		return 0, 0, false
+	}
+
+	return teamID, memberID, true
+}
+
+type TeamRolesResponse struct {
+	BuiltIn map[models.TeamRole][]authz.Action `json:"built_in"`
+	Custom  []models.TeamRoleDefinition        `json:"custom"`
+}
+
+func (h *TeamHandler) GetRoles(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
+		return
+	}
+
+	roles, err := h.teamService.GetRoles(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, TeamRolesResponse{BuiltIn: authz.BuiltInRoles(), Custom: roles})
+}
+
+func (h *TeamHandler) CreateRole(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
+		return
+	}
+
+	var input services.TeamRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input: " + err.Error()})
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	role, err := h.teamService.CreateRole(c.Request.Context(), id, userID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+func (h *TeamHandler) UpdateRole(c *gin.Context) {
+	teamID, roleID, ok := parseTeamRoleIDs(c)
+	if !ok {
+		return
+	}
+
+	var input services.TeamRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input: " + err.Error()})
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	role, err := h.teamService.UpdateRole(c.Request.Context(), teamID, roleID, userID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+func (h *TeamHandler) DeleteRole(c *gin.Context) {
+	teamID, roleID, ok := parseTeamRoleIDs(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	err := h.teamService.DeleteRole(c.Request.Context(), teamID, roleID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Role deleted successfully"})
+}
+
+func parseTeamRoleIDs(c *gin.Context) (int64, int64, bool) {
+	teamID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
+		return 0, 0, false
+	}
+
+	roleID, err := strconv.ParseInt(c.Param("role_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid role ID"})
+		return 0, 0, false
+	}
+
+	return teamID, roleID, true
+}
+
+func (h *TeamHandler) ImportResources(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
+		return
+	}
+
+	var input services.ImportResourcesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input: " + err.Error()})
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	result, err := h.teamService.ImportResources(c.Request.Context(), id, userID, input)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *TeamHandler) GetUsage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
+		return
+	}
+
+	usage, err := h.quotaService.GetUsage(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+func (h *TeamHandler) GetAuditLog(c *gin.Context) {
+	teamID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid team ID"})
+		return
+	}
+
+	filter := services.AuditFilter{
+		Action:       c.Query("action"),
+		ResourceType: c.Query("resource_type"),
+	}
+
+	ints := map[string]*int64{
+		"actor_id":    &filter.ActorID,
+		"resource_id": &filter.ResourceID,
+		"cursor":      &filter.Cursor,
+	}
+	for name, target := range ints {
+		if value := c.Query(name); value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || parsed < 1 {
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid " + name})
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	times := map[string]**time.Time{
+		"since": &filter.Since,
+		"until": &filter.Until,
+	}
+	for name, target := range times {
+		if value := c.Query(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid " + name + ": expected an RFC3339 timestamp"})
+				return
+			}
+			*target = &parsed
+		}
+	}
+
+	if limit, err := strconv.Atoi(c.DefaultQuery("limit", "50")); err == nil {
+		filter.Limit = limit
+	}
+
+	events, nextCursor, err := h.auditService.List(c.Request.Context(), teamID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := CursorResponse{Data: events}
+	if nextCursor != 0 {
+		response.NextCursor = strconv.FormatInt(nextCursor, 10)
+	}
+
+	c.JSON(http.StatusOK, response)
 }
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/open-move/intercord/internal/services"
+)
+
+type UserHandler struct {
+	userService    *services.UserService
+	sessionService *services.SessionService
+	baseURL        string
+}
+
+func NewUserHandler(userService *services.UserService, sessionService *services.SessionService, baseURL string) *UserHandler {
+	return &UserHandler{
+		userService:    userService,
+		sessionService: sessionService,
+		baseURL:        baseURL,
+	}
+}
+
+func (h *UserHandler) GetMe(c *gin.Context) {
+	userID := c.GetInt64("userID")
+	user, err := h.userService.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *UserHandler) UpdateMe(c *gin.Context) {
+	var input services.UpdateProfileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input: " + err.Error()})
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	user, err := h.userService.UpdateProfile(c.Request.Context(), userID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	var input services.ChangePasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input: " + err.Error()})
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	sessionID := c.GetInt64("sessionID")
+	err := h.userService.ChangePassword(c.Request.Context(), userID, sessionID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Password changed successfully; other sessions have been signed out"})
+}
+
+func (h *UserHandler) ChangeEmail(c *gin.Context) {
+	var input services.ChangeEmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input: " + err.Error()})
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	err := h.userService.RequestEmailChange(c.Request.Context(), userID, input, h.baseURL)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "A confirmation link has been sent to the new email address"})
+}
+
+func (h *UserHandler) DeleteMe(c *gin.Context) {
+	var input services.DeleteAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input: " + err.Error()})
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	err := h.userService.DeleteAccount(c.Request.Context(), userID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Account deleted successfully"})
+}
+
+func (h *UserHandler) GetSessions(c *gin.Context) {
+	userID := c.GetInt64("userID")
+	sessionID := c.GetInt64("sessionID")
+	sessions, err := h.sessionService.List(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid session ID"})
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	err = h.sessionService.Revoke(c.Request.Context(), userID, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Session revoked successfully"})
+}
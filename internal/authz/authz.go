@@ -0,0 +1,215 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/open-move/intercord/internal/models"
+)
+
+type Action string
+
+const (
+	TeamRead              Action = "team:read"
+	TeamUpdate            Action = "team:update"
+	TeamDelete            Action = "team:delete"
+	TeamManageMembers     Action = "team:manage_members"
+	TeamManageRoles       Action = "team:manage_roles"
+	TeamTransferOwnership Action = "team:transfer_ownership"
+	TeamReadAuditLog      Action = "team:read_audit_log"
+
+	SubscriptionCreate Action = "subscription:create"
+	SubscriptionRead   Action = "subscription:read"
+	SubscriptionUpdate Action = "subscription:update"
+	SubscriptionDelete Action = "subscription:delete"
+
+	ChannelCreate Action = "channel:create"
+	ChannelRead   Action = "channel:read"
+	ChannelUpdate Action = "channel:update"
+	ChannelDelete Action = "channel:delete"
+	ChannelUse    Action = "channel:use"
+
+	NotificationRead    Action = "notification:read"
+	NotificationRedrive Action = "notification:redrive"
+)
+
+var ErrForbidden = errors.New("you don't have permission to perform this action")
+
+var memberActions = []Action{
+	TeamRead,
+	SubscriptionRead,
+	ChannelRead,
+	NotificationRead,
+}
+
+var adminActions = append([]Action{
+	TeamUpdate,
+	TeamManageMembers,
+	TeamManageRoles,
+	TeamReadAuditLog,
+	SubscriptionCreate,
+	SubscriptionUpdate,
+	SubscriptionDelete,
+	ChannelCreate,
+	ChannelUpdate,
+	ChannelDelete,
+	ChannelUse,
+	NotificationRedrive,
+}, memberActions...)
+
+var ownerActions = append([]Action{
+	TeamDelete,
+	TeamTransferOwnership,
+}, adminActions...)
+
+var rolePolicy = map[models.TeamRole][]Action{
+	models.TeamRoleOwner:  ownerActions,
+	models.TeamRoleAdmin:  adminActions,
+	models.TeamRoleMember: memberActions,
+}
+
+func BuiltInRoles() map[models.TeamRole][]Action {
+	return rolePolicy
+}
+
+// ParseActions validates the permissions of a custom role. Deleting a team and
+// transferring it stay with the owner, so no custom role can grant them.
+func ParseActions(names []string) ([]Action, error) {
+	actions := make([]Action, 0, len(names))
+	seen := map[Action]bool{}
+
+	for _, name := range names {
+		action := Action(name)
+		if action == TeamDelete || action == TeamTransferOwnership {
+			return nil, fmt.Errorf("permission %q is reserved for the team owner", name)
+		}
+
+		if !contains(ownerActions, action) {
+			return nil, fmt.Errorf("unknown permission %q", name)
+		}
+
+		if !seen[action] {
+			seen[action] = true
+			actions = append(actions, action)
+		}
+	}
+
+	return actions, nil
+}
+
+// Permissions resolves what a membership may do. Custom roles replace the
+// member defaults, but every member can still see the team itself.
+func Permissions(membership *models.TeamMembership) []Action {
+	if membership.Role == models.TeamRoleOwner || membership.CustomRole == nil {
+		return rolePolicy[membership.Role]
+	}
+
+	actions := []Action{TeamRead}
+	for _, permission := range membership.CustomRole.Permissions {
+		if action := Action(permission); action != TeamRead {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+func Allows(membership *models.TeamMembership, action Action) bool {
+	return contains(Permissions(membership), action)
+}
+
+// TeamsAllowing returns the teams whose membership allows action, for listings
+// that gather resources across every team a user belongs to.
+func TeamsAllowing(memberships []models.TeamMembership, action Action) []int64 {
+	var teamIDs []int64
+	for i := range memberships {
+		if Allows(&memberships[i], action) {
+			teamIDs = append(teamIDs, memberships[i].TeamID)
+		}
+	}
+	return teamIDs
+}
+
+// CanGrant reports whether a member may hand a built-in role to someone else,
+// which takes every permission the role carries.
+func CanGrant(granter *models.TeamMembership, role models.TeamRole) bool {
+	return Covers(Permissions(granter), rolePolicy[role])
+}
+
+func Covers(granted, requested []Action) bool {
+	for _, action := range requested {
+		if !contains(granted, action) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(actions []Action, action Action) bool {
+	for _, candidate := range actions {
+		if candidate == action {
+			return true
+		}
+	}
+	return false
+}
+
+type Resource struct {
+	OwnerID int64
+	TeamID  *int64
+}
+
+func TeamResource(teamID int64) Resource {
+	return Resource{TeamID: &teamID}
+}
+
+// TrashedTeamResource treats a deleted team as its owner's: it has no active
+// members until it is restored.
+func TrashedTeamResource(team *models.Team) Resource {
+	return Resource{OwnerID: team.OwnerID}
+}
+
+func SubscriptionResource(subscription *models.Subscription) Resource {
+	return Resource{OwnerID: subscription.UserID, TeamID: subscription.TeamID}
+}
+
+func ChannelResource(channel *models.Channel) Resource {
+	return Resource{OwnerID: channel.UserID, TeamID: channel.TeamID}
+}
+
+type MembershipLookup interface {
+	GetMembership(ctx context.Context, teamID, userID int64) (*models.TeamMembership, error)
+}
+
+type Authorizer struct {
+	memberships MembershipLookup
+}
+
+func NewAuthorizer(memberships MembershipLookup) *Authorizer {
+	return &Authorizer{
+		memberships: memberships,
+	}
+}
+
+// Authorize allows the creator of a personal resource everything on it. Team
+// resources are governed by the caller's role, except that members keep full
+// control over the resources they created themselves.
+func (a *Authorizer) Authorize(ctx context.Context, userID int64, action Action, resource Resource) error {
+	if resource.TeamID == nil {
+		if resource.OwnerID != 0 && resource.OwnerID == userID {
+			return nil
+		}
+		return ErrForbidden
+	}
+
+	membership, err := a.memberships.GetMembership(ctx, *resource.TeamID, userID)
+	if err != nil {
+		return ErrForbidden
+	}
+
+	if resource.OwnerID == userID || Allows(membership, action) {
+		return nil
+	}
+
+	return ErrForbidden
+}
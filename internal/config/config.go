@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,6 +13,12 @@ type Config struct {
 	Database DatabaseConfig
 	JWT      JWTConfig
 	Email    EmailConfig
+	Throttle ThrottleConfig
+	Auth     AuthConfig
+	Export   ExportConfig
+	Teams    TeamsConfig
+	Trash    TrashConfig
+	Sui      SuiConfig
 }
 
 type ServerConfig struct {
@@ -18,6 +26,9 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+	// TrustedProxies lists the proxy IPs or CIDRs whose forwarding headers
+	// are believed when resolving a client's IP. Empty trusts none.
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
@@ -45,6 +56,54 @@ type EmailConfig struct {
 	FromName     string
 }
 
+type ThrottleConfig struct {
+	Store              string
+	Window             time.Duration
+	FreeAttempts       int
+	BaseDelay          time.Duration
+	MaxDelay           time.Duration
+	MaxIPFailures      int
+	MaxAccountFailures int
+	MaxResetRequests   int
+	MaxResendRequests  int
+	LockoutDuration    time.Duration
+}
+
+type AuthConfig struct {
+	RequireEmailVerification bool
+	VerificationTokenTTL     time.Duration
+	TokenCleanupInterval     time.Duration
+}
+
+type ExportConfig struct {
+	Dir     string
+	LinkTTL time.Duration
+}
+
+type TeamsConfig struct {
+	InvitationTTL time.Duration
+	DefaultPlan   string
+}
+
+type TrashConfig struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+type SuiConfig struct {
+	RPCURL         string
+	RequestTimeout time.Duration
+	EventTypeCheck string
+	ModuleCacheTTL time.Duration
+	PollInterval   time.Duration
+	PollPageSize   int
+	PollMaxPages   int
+
+	// BalanceReconcileInterval is how often balance subscriptions re-read
+	// their balance from the node.
+	BalanceReconcileInterval time.Duration
+}
+
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -65,6 +124,28 @@ func getEnvInt(key string, defaultValue int) int {
 	return intValue
 }
 
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+	return boolValue
+}
+
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	value := os.Getenv(key)
 	if value == "" {
@@ -80,10 +161,11 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 func Load() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port:         getEnv("SERVER_PORT", "8080"),
-			ReadTimeout:  getEnvDuration("SERVER_READ_TIMEOUT", 10*time.Second),
-			WriteTimeout: getEnvDuration("SERVER_WRITE_TIMEOUT", 10*time.Second),
-			IdleTimeout:  getEnvDuration("SERVER_IDLE_TIMEOUT", 120*time.Second),
+			Port:           getEnv("SERVER_PORT", "8080"),
+			ReadTimeout:    getEnvDuration("SERVER_READ_TIMEOUT", 10*time.Second),
+			WriteTimeout:   getEnvDuration("SERVER_WRITE_TIMEOUT", 10*time.Second),
+			IdleTimeout:    getEnvDuration("SERVER_IDLE_TIMEOUT", 120*time.Second),
+			TrustedProxies: getEnvList("SERVER_TRUSTED_PROXIES"),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -107,5 +189,45 @@ func Load() *Config {
 			FromEmail:    getEnv("EMAIL_FROM", "noreply@intercord.io"),
 			FromName:     getEnv("EMAIL_NAME", "Intercord"),
 		},
+		Throttle: ThrottleConfig{
+			Store:              getEnv("AUTH_THROTTLE_STORE", "memory"),
+			Window:             getEnvDuration("AUTH_THROTTLE_WINDOW", 15*time.Minute),
+			FreeAttempts:       getEnvInt("AUTH_THROTTLE_FREE_ATTEMPTS", 3),
+			BaseDelay:          getEnvDuration("AUTH_THROTTLE_BASE_DELAY", time.Second),
+			MaxDelay:           getEnvDuration("AUTH_THROTTLE_MAX_DELAY", 30*time.Second),
+			MaxIPFailures:      getEnvInt("AUTH_THROTTLE_MAX_IP_FAILURES", 50),
+			MaxAccountFailures: getEnvInt("AUTH_THROTTLE_MAX_ACCOUNT_FAILURES", 10),
+			MaxResetRequests:   getEnvInt("AUTH_THROTTLE_MAX_RESET_REQUESTS", 5),
+			MaxResendRequests:  getEnvInt("AUTH_THROTTLE_MAX_RESEND_REQUESTS", 3),
+			LockoutDuration:    getEnvDuration("AUTH_LOCKOUT_DURATION", 15*time.Minute),
+		},
+		Auth: AuthConfig{
+			RequireEmailVerification: getEnvBool("AUTH_REQUIRE_EMAIL_VERIFICATION", true),
+			VerificationTokenTTL:     getEnvDuration("AUTH_VERIFICATION_TOKEN_TTL", 24*time.Hour),
+			TokenCleanupInterval:     getEnvDuration("AUTH_TOKEN_CLEANUP_INTERVAL", time.Hour),
+		},
+		Export: ExportConfig{
+			Dir:     getEnv("EXPORT_DIR", filepath.Join(os.TempDir(), "intercord-exports")),
+			LinkTTL: getEnvDuration("EXPORT_LINK_TTL", 24*time.Hour),
+		},
+		Teams: TeamsConfig{
+			InvitationTTL: getEnvDuration("TEAM_INVITATION_TTL", 7*24*time.Hour),
+			DefaultPlan:   getEnv("TEAM_DEFAULT_PLAN", "free"),
+		},
+		Trash: TrashConfig{
+			Retention:     getEnvDuration("TRASH_RETENTION", 30*24*time.Hour),
+			PurgeInterval: getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
+		},
+		Sui: SuiConfig{
+			RPCURL:         getEnv("SUI_RPC_URL", "https://fullnode.mainnet.sui.io:443"),
+			RequestTimeout: getEnvDuration("SUI_RPC_TIMEOUT", 10*time.Second),
+			EventTypeCheck: getEnv("SUI_EVENT_TYPE_CHECK", "reject"),
+			ModuleCacheTTL: getEnvDuration("SUI_MODULE_CACHE_TTL", time.Hour),
+			PollInterval:   getEnvDuration("SUI_POLL_INTERVAL", 5*time.Second),
+			PollPageSize:   getEnvInt("SUI_POLL_PAGE_SIZE", 50),
+			PollMaxPages:   getEnvInt("SUI_POLL_MAX_PAGES", 20),
+
+			BalanceReconcileInterval: getEnvDuration("SUI_BALANCE_RECONCILE_INTERVAL", 10*time.Minute),
+		},
 	}
 }
\ No newline at end of file
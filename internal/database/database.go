@@ -31,13 +31,26 @@ func RunMigrations(db *bun.DB) error {
 	models := []interface{}{
 		(*models.User)(nil),
 		(*models.Team)(nil),
+		(*models.TeamRoleDefinition)(nil),
 		(*models.TeamMembership)(nil),
+		(*models.TeamInvitation)(nil),
+		(*models.TeamJoinRequest)(nil),
+		(*models.TeamInviteLink)(nil),
+		(*models.TeamInviteLinkRedemption)(nil),
+		(*models.TeamAuditEvent)(nil),
+		(*models.TeamUsage)(nil),
 		(*models.Subscription)(nil),
 		(*models.Channel)(nil),
 		(*models.SubscriptionChannel)(nil),
 		(*models.Notification)(nil),
+		(*models.IngestionCursor)(nil),
 		(*models.PasswordReset)(nil),
 		(*models.EmailVerification)(nil),
+		(*models.EmailChange)(nil),
+		(*models.Session)(nil),
+		(*models.DataExport)(nil),
+		(*models.AuthAttempt)(nil),
+		(*models.AuthThrottle)(nil),
 	}
 
 	for _, model := range models {
@@ -48,5 +61,51 @@ func RunMigrations(db *bun.DB) error {
 		}
 	}
 
+	// Tables that already exist are left alone above, so columns added to
+	// them later are added here.
+	columns := []addedColumn{
+		{"teams", "join_policy VARCHAR NOT NULL DEFAULT 'invite_only'"},
+		{"teams", "allowed_domains VARCHAR[]"},
+		{"team_memberships", "custom_role_id BIGINT"},
+		{"teams", "plan VARCHAR NOT NULL DEFAULT 'free'"},
+		{"subscriptions", "event_fields JSONB"},
+		{"subscriptions", "filter VARCHAR"},
+		{"subscriptions", "senders JSONB"},
+		{"subscriptions", "emitter_package VARCHAR"},
+		{"subscriptions", "emitter_module VARCHAR"},
+		{"subscriptions", "transaction_kinds JSONB"},
+		{"subscriptions", "kind VARCHAR NOT NULL DEFAULT 'event'"},
+		{"subscriptions", "addresses JSONB"},
+		{"subscriptions", "address_direction VARCHAR"},
+		{"subscriptions", "object_ids JSONB"},
+		{"subscriptions", "object_type VARCHAR"},
+		{"subscriptions", "object_change_types JSONB"},
+		{"subscriptions", "balance_address VARCHAR"},
+		{"subscriptions", "coin_type VARCHAR"},
+		{"subscriptions", "coin_decimals BIGINT"},
+		{"subscriptions", "threshold VARCHAR"},
+		{"subscriptions", "threshold_direction VARCHAR"},
+		{"subscriptions", "hysteresis VARCHAR"},
+		{"subscriptions", "balance VARCHAR"},
+		{"subscriptions", "triggered BOOLEAN NOT NULL DEFAULT false"},
+		{"subscriptions", "package_id VARCHAR"},
+		{"subscriptions", "upgrade_cap_id VARCHAR"},
+		{"subscriptions", "system_events JSONB"},
+		{"subscriptions", "validators JSONB"},
+	}
+
+	for _, column := range columns {
+		_, err := db.NewAddColumn().Table(column.table).ColumnExpr(column.definition).IfNotExists().Exec(ctx)
+		if err != nil {
+			log.Printf("Error adding column %q to %s: %v", column.definition, column.table, err)
+			return err
+		}
+	}
+
 	return nil
 }
+
+type addedColumn struct {
+	table      string
+	definition string
+}
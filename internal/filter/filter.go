@@ -0,0 +1,257 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/ast"
+
+	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/sui"
+)
+
+// MaxLength caps the size of a filter expression.
+const MaxLength = 2000
+
+// costLimit bounds the work one evaluation may do, so an expensive filter
+// can't stall ingestion. Evaluations that exceed it count as no match.
+const costLimit = 10000
+
+var (
+	envOnce sync.Once
+	env     *cel.Env
+	envErr  error
+)
+
+// environment declares what a filter can refer to: the event's parsedJson as
+// event, and the sender, package_id, module, event_type, timestamp and
+// tx_digest of its transaction.
+func environment() (*cel.Env, error) {
+	envOnce.Do(func() {
+		env, envErr = cel.NewEnv(
+			cel.Variable("event", cel.MapType(cel.StringType, cel.DynType)),
+			cel.Variable("sender", cel.StringType),
+			cel.Variable("package_id", cel.StringType),
+			cel.Variable("module", cel.StringType),
+			cel.Variable("event_type", cel.StringType),
+			cel.Variable("timestamp", cel.TimestampType),
+			cel.Variable("tx_digest", cel.StringType),
+			cel.ParserExpressionSizeLimit(MaxLength),
+			cel.CrossTypeNumericComparisons(true),
+		)
+	})
+	return env, envErr
+}
+
+// Program is a compiled filter expression.
+type Program struct {
+	program cel.Program
+	fields  map[string]string
+}
+
+// stdlib is the full-length address of the Move standard library, 0x1.
+var stdlib = "0x" + strings.Repeat("0", 63) + "1"
+
+// fieldType is the CEL type of an event field. Integers up to u64 are exposed
+// as uint rather than the strings Sui encodes them as; u128 and u256 don't fit
+// in a uint and stay strings. Other types are left dynamic.
+func fieldType(moveType string) *cel.Type {
+	switch moveType {
+	case "bool":
+		return cel.BoolType
+	case "u8", "u16", "u32", "u64":
+		return cel.UintType
+	case "u128", "u256", "address", stdlib + "::string::String", stdlib + "::ascii::String":
+		return cel.StringType
+	default:
+		return cel.DynType
+	}
+}
+
+// Compile parses and type-checks a filter. When the event's field layout is
+// known, references to fields the event doesn't have are rejected and
+// event.name is declared with the field's type, so a comparison that can never
+// succeed, such as a u128 field with a number, is rejected here instead of
+// failing on every event.
+func Compile(expression string, layout []models.EventField) (*Program, error) {
+	env, err := environment()
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]string
+	if len(layout) > 0 {
+		fields = make(map[string]string, len(layout))
+		variables := make([]cel.EnvOption, 0, len(layout))
+		for _, field := range layout {
+			fields[field.Name] = field.Type
+			// The checker resolves event.name to this variable before
+			// looking into the event map.
+			variables = append(variables, cel.Variable("event."+field.Name, fieldType(field.Type)))
+		}
+
+		if env, err = env.Extend(variables...); err != nil {
+			return nil, err
+		}
+	}
+
+	checked, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+
+	if output := checked.OutputType(); !output.IsExactType(cel.BoolType) && !output.IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("filter must evaluate to a bool, not %s", output)
+	}
+
+	if fields != nil {
+		if err := checkFields(checked.NativeRep(), fields); err != nil {
+			return nil, err
+		}
+	}
+
+	program, err := env.Program(checked, cel.CostLimit(costLimit))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Program{program: program, fields: fields}, nil
+}
+
+// Matches evaluates the filter against an event.
+func (p *Program) Matches(event sui.Event) (bool, error) {
+	fields, err := p.decode(event.ParsedJSON)
+	if err != nil {
+		return false, err
+	}
+
+	vars := map[string]interface{}{
+		"event":      fields,
+		"sender":     event.Sender,
+		"package_id": event.PackageID,
+		"module":     event.TransactionModule,
+		"event_type": event.Type,
+		"timestamp":  event.Timestamp(),
+		"tx_digest":  event.ID.TxDigest,
+	}
+	for name := range p.fields {
+		if value, ok := fields[name]; ok {
+			vars["event."+name] = value
+		}
+	}
+
+	result, _, err := p.program.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+
+	matched, ok := result.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("filter returned %v instead of a bool", result.Value())
+	}
+
+	return matched, nil
+}
+
+func (p *Program) decode(parsedJSON json.RawMessage) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if len(bytes.TrimSpace(parsedJSON)) == 0 {
+		return fields, nil
+	}
+
+	// Numbers are kept as written so u8-u32 fields, which the node sends as
+	// JSON numbers, don't lose their digits to float64 formatting.
+	decoder := json.NewDecoder(bytes.NewReader(parsedJSON))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("decoding event fields: %w", err)
+	}
+
+	for name, value := range fields {
+		if !fieldType(p.fields[name]).IsExactType(cel.UintType) {
+			fields[name] = floats(value)
+			continue
+		}
+
+		var digits string
+		switch value := value.(type) {
+		case json.Number:
+			digits = value.String()
+		case string:
+			digits = value
+		}
+
+		if number, err := strconv.ParseUint(digits, 10, 64); err == nil {
+			fields[name] = number
+		} else {
+			fields[name] = floats(value)
+		}
+	}
+
+	return fields, nil
+}
+
+// floats turns the json.Number values in a decoded field back into the
+// float64s CEL expects for untyped JSON.
+func floats(value interface{}) interface{} {
+	switch value := value.(type) {
+	case json.Number:
+		number, _ := value.Float64()
+		return number
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = floats(item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = floats(item)
+		}
+	}
+	return value
+}
+
+// checkFields rejects event.name and event["name"] references to fields that
+// are not in the layout.
+func checkFields(checked *ast.AST, fields map[string]string) error {
+	var unknown error
+	ast.PreOrderVisit(checked.Expr(), ast.NewExprVisitor(func(e ast.Expr) {
+		if unknown != nil {
+			return
+		}
+
+		var operand ast.Expr
+		var name string
+		switch e.Kind() {
+		case ast.SelectKind:
+			operand, name = e.AsSelect().Operand(), e.AsSelect().FieldName()
+		case ast.CallKind:
+			call := e.AsCall()
+			if call.FunctionName() != "_[_]" || len(call.Args()) != 2 || call.Args()[1].Kind() != ast.LiteralKind {
+				return
+			}
+			key, ok := call.Args()[1].AsLiteral().Value().(string)
+			if !ok {
+				return
+			}
+			operand, name = call.Args()[0], key
+		default:
+			return
+		}
+
+		if operand.Kind() != ast.IdentKind || operand.AsIdent() != "event" {
+			return
+		}
+
+		if _, ok := fields[name]; !ok {
+			location := checked.SourceInfo().GetStartLocation(e.ID())
+			unknown = fmt.Errorf("event has no field %q (line %d, column %d)", name, location.Line(), location.Column()+1)
+		}
+	}))
+
+	return unknown
+}
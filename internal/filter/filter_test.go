@@ -0,0 +1,126 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/sui"
+)
+
+var layout = []models.EventField{
+	{Name: "amount", Type: "u64"},
+	{Name: "level", Type: "u8"},
+	{Name: "count", Type: "u32"},
+	{Name: "supply", Type: "u128"},
+	{Name: "owner", Type: "address"},
+	{Name: "name", Type: stdlib + "::string::String"},
+	{Name: "active", Type: "bool"},
+	{Name: "tags", Type: "vector<u8>"},
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		expression string
+		layout     []models.EventField
+		err        string
+	}{
+		{`event.amount > 1000`, layout, ""},
+		{`event.amount > 1000u && event.level < 3`, layout, ""},
+		{`event.amount > 1.5`, layout, ""},
+		{`event["amount"] > 1000`, layout, ""},
+		{`event.supply == "340282366920938463463374607431768211455"`, layout, ""},
+		{`event.owner == sender && event.active`, layout, ""},
+		{`event.name.startsWith("a") && size(event.tags) > 0`, layout, ""},
+		{`has(event.amount)`, layout, ""},
+		{`event.anything > 1 && event.other == "x"`, nil, ""},
+		{`event.supply > 1000`, layout, "no matching overload"},
+		{`event.amount == "1000"`, layout, "no matching overload"},
+		{`event.active == 1`, layout, "no matching overload"},
+		{`event.missing == 1`, layout, `event has no field "missing" (line 1, column 6)`},
+		{`event["missing"] == 1`, layout, `event has no field "missing"`},
+		{`event.amount`, layout, "filter must evaluate to a bool, not uint"},
+		{`sender`, nil, "filter must evaluate to a bool, not string"},
+		{`sender ==`, nil, "Syntax error"},
+		{`unknown == 1`, nil, "undeclared reference"},
+	}
+
+	for _, tt := range tests {
+		_, err := Compile(tt.expression, tt.layout)
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.expression, err)
+			}
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got %v, want an error containing %q", tt.expression, err, tt.err)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	event := sui.Event{
+		ID:                sui.EventID{TxDigest: "digest", EventSeq: "0"},
+		PackageID:         "0x2",
+		TransactionModule: "pool",
+		Sender:            "0xabc",
+		Type:              "0x2::pool::Swap",
+		ParsedJSON:        []byte(`{"amount": "5000", "level": 2, "count": 2000000, "supply": "340282366920938463463374607431768211455", "owner": "0xabc", "name": "alice", "active": true, "tags": [1, 2]}`),
+		TimestampMs:       "1700000000000",
+	}
+
+	tests := []struct {
+		expression string
+		layout     []models.EventField
+		want       bool
+		err        bool
+	}{
+		{`event.amount > 1000`, layout, true, false},
+		{`event.amount > 5000u`, layout, false, false},
+		{`event["amount"] == 5000u`, layout, true, false},
+		{`event.level == 2u`, layout, true, false},
+		{`event.count % 2u == 0u && event.count == 2000000u`, layout, true, false},
+		{`size(event.tags) == 2 && event.tags[0] == 1`, layout, true, false},
+		{`event.supply == "340282366920938463463374607431768211455"`, layout, true, false},
+		{`event.owner == sender && event.active && event.name == "alice"`, layout, true, false},
+		{`size(event.tags) == 2`, layout, true, false},
+		{`module == "pool" && package_id == "0x2" && tx_digest == "digest"`, layout, true, false},
+		{`event_type.endsWith("::Swap")`, nil, true, false},
+		{`timestamp > timestamp("2023-01-01T00:00:00Z")`, nil, true, false},
+		{`event.amount == "5000"`, nil, true, false},
+		{`event.amount > 1000`, nil, false, true},
+		{`event.nothing == 1`, nil, false, true},
+	}
+
+	for _, tt := range tests {
+		program, err := Compile(tt.expression, tt.layout)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.expression, err)
+		}
+
+		got, err := program.Matches(event)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: got error %v, want error %v", tt.expression, err, tt.err)
+		}
+
+		if got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.expression, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesWithoutFields(t *testing.T) {
+	program, err := Compile(`!has(event.amount)`, layout)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, parsedJSON := range []string{"", "{}"} {
+		matched, err := program.Matches(sui.Event{ParsedJSON: []byte(parsedJSON)})
+		if err != nil || !matched {
+			t.Errorf("%q: got %v, %v, want a match", parsedJSON, matched, err)
+		}
+	}
+}
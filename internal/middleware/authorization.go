@@ -0,0 +1,262 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/open-move/intercord/internal/authz"
+	"github.com/open-move/intercord/internal/models"
+)
+
+type ResourceSource int
+
+const (
+	TeamParam ResourceSource = iota + 1
+	SubscriptionParam
+	ChannelParam
+	NotificationParam
+	TrashedTeamParam
+	TrashedSubscriptionParam
+	TrashedChannelParam
+	TeamBody
+	SubscriptionBody
+	ChannelBody
+)
+
+type Permission struct {
+	Action authz.Action
+	Source ResourceSource
+}
+
+// Policy maps "METHOD /route/:pattern" to the permissions a caller needs. An
+// empty list marks an endpoint that only touches the caller's own data;
+// endpoints missing from the policy are rejected.
+type Policy map[string][]Permission
+
+type TeamLookup interface {
+	GetTrashedByID(ctx context.Context, id int64) (*models.Team, error)
+}
+
+type SubscriptionLookup interface {
+	GetByID(ctx context.Context, id int64) (*models.Subscription, error)
+	GetTrashedByID(ctx context.Context, id int64) (*models.Subscription, error)
+}
+
+type ChannelLookup interface {
+	GetByID(ctx context.Context, id int64) (*models.Channel, error)
+	GetTrashedByID(ctx context.Context, id int64) (*models.Channel, error)
+}
+
+type NotificationLookup interface {
+	GetByID(ctx context.Context, id int64) (*models.Notification, error)
+}
+
+type AuthorizationMiddleware struct {
+	authorizer    *authz.Authorizer
+	teams         TeamLookup
+	subscriptions SubscriptionLookup
+	channels      ChannelLookup
+	notifications NotificationLookup
+}
+
+func NewAuthorizationMiddleware(authorizer *authz.Authorizer, teams TeamLookup, subscriptions SubscriptionLookup, channels ChannelLookup, notifications NotificationLookup) *AuthorizationMiddleware {
+	return &AuthorizationMiddleware{
+		authorizer:    authorizer,
+		teams:         teams,
+		subscriptions: subscriptions,
+		channels:      channels,
+		notifications: notifications,
+	}
+}
+
+type bodyReferences struct {
+	TeamID         *int64 `json:"team_id"`
+	SubscriptionID int64  `json:"subscription_id"`
+	ChannelID      int64  `json:"channel_id"`
+}
+
+func (m *AuthorizationMiddleware) Enforce(policy Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		permissions, ok := policy[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "No authorization policy for this endpoint"})
+			return
+		}
+
+		if len(permissions) == 0 {
+			c.Next()
+			return
+		}
+
+		var refs *bodyReferences
+		userID := c.GetInt64("userID")
+
+		for _, permission := range permissions {
+			if refs == nil && permission.Source >= TeamBody {
+				var err error
+				if refs, err = readBodyReferences(c); err != nil {
+					c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+					return
+				}
+			}
+
+			resource, skip, err := m.resolve(c, permission.Source, refs)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+
+			if skip {
+				continue
+			}
+
+			if err := m.authorizer.Authorize(c.Request.Context(), userID, permission.Action, resource); err != nil {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+func (m *AuthorizationMiddleware) resolve(c *gin.Context, source ResourceSource, refs *bodyReferences) (authz.Resource, bool, error) {
+	ctx := c.Request.Context()
+
+	switch source {
+	case TeamParam:
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return authz.Resource{}, false, errors.New("team not found")
+		}
+		return authz.TeamResource(id), false, nil
+
+	case SubscriptionParam:
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return authz.Resource{}, false, errors.New("subscription not found")
+		}
+		return m.subscriptionResource(ctx, id)
+
+	case NotificationParam:
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return authz.Resource{}, false, errors.New("notification not found")
+		}
+
+		notification, err := m.notifications.GetByID(ctx, id)
+		if err != nil {
+			return authz.Resource{}, false, errors.New("notification not found")
+		}
+		return m.subscriptionResource(ctx, notification.SubscriptionID)
+
+	case ChannelParam:
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return authz.Resource{}, false, errors.New("channel not found")
+		}
+		return m.channelResource(ctx, id)
+
+	case TrashedTeamParam:
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return authz.Resource{}, false, errors.New("team not found in trash")
+		}
+
+		team, err := m.teams.GetTrashedByID(ctx, id)
+		if err != nil {
+			return authz.Resource{}, false, errors.New("team not found in trash")
+		}
+		return authz.TrashedTeamResource(team), false, nil
+
+	case TrashedSubscriptionParam:
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return authz.Resource{}, false, errors.New("subscription not found in trash")
+		}
+
+		subscription, err := m.subscriptions.GetTrashedByID(ctx, id)
+		if err != nil {
+			return authz.Resource{}, false, errors.New("subscription not found in trash")
+		}
+		return authz.SubscriptionResource(subscription), false, nil
+
+	case TrashedChannelParam:
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return authz.Resource{}, false, errors.New("channel not found in trash")
+		}
+
+		channel, err := m.channels.GetTrashedByID(ctx, id)
+		if err != nil {
+			return authz.Resource{}, false, errors.New("channel not found in trash")
+		}
+		return authz.ChannelResource(channel), false, nil
+
+	case TeamBody:
+		if refs.TeamID == nil || *refs.TeamID == 0 {
+			return authz.Resource{}, true, nil
+		}
+		return authz.TeamResource(*refs.TeamID), false, nil
+
+	case SubscriptionBody:
+		if refs.SubscriptionID == 0 {
+			return authz.Resource{}, true, nil
+		}
+		return m.subscriptionResource(ctx, refs.SubscriptionID)
+
+	case ChannelBody:
+		if refs.ChannelID == 0 {
+			return authz.Resource{}, true, nil
+		}
+		return m.channelResource(ctx, refs.ChannelID)
+	}
+
+	return authz.Resource{}, false, errors.New("unknown resource")
+}
+
+func (m *AuthorizationMiddleware) subscriptionResource(ctx context.Context, id int64) (authz.Resource, bool, error) {
+	subscription, err := m.subscriptions.GetByID(ctx, id)
+	if err != nil {
+		return authz.Resource{}, false, errors.New("subscription not found")
+	}
+	return authz.SubscriptionResource(subscription), false, nil
+}
+
+func (m *AuthorizationMiddleware) channelResource(ctx context.Context, id int64) (authz.Resource, bool, error) {
+	channel, err := m.channels.GetByID(ctx, id)
+	if err != nil {
+		return authz.Resource{}, false, errors.New("channel not found")
+	}
+	return authz.ChannelResource(channel), false, nil
+}
+
+func readBodyReferences(c *gin.Context) (*bodyReferences, error) {
+	refs := new(bodyReferences)
+	if c.Request.Body == nil {
+		return refs, nil
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return refs, nil
+	}
+
+	if err := json.Unmarshal(body, refs); err != nil {
+		return nil, err
+	}
+
+	return refs, nil
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strings"
@@ -13,13 +14,19 @@ import (
 	"github.com/open-move/intercord/internal/utils"
 )
 
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, claims *utils.Claims) error
+}
+
 type JWTAuthMiddleware struct {
-	config *config.JWTConfig
+	config    *config.JWTConfig
+	validator TokenValidator
 }
 
-func NewJWTAuthMiddleware(config *config.JWTConfig) *JWTAuthMiddleware {
+func NewJWTAuthMiddleware(config *config.JWTConfig, validator TokenValidator) *JWTAuthMiddleware {
 	return &JWTAuthMiddleware{
-		config: config,
+		config:    config,
+		validator: validator,
 	}
 }
 
@@ -44,7 +51,13 @@ func (m *JWTAuthMiddleware) AuthRequired() gin.HandlerFunc {
 			return
 		}
 
+		if err := m.validator.ValidateToken(c.Request.Context(), claims); err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			return
+		}
+
 		c.Set("userID", claims.UserID)
+		c.Set("sessionID", claims.SessionID)
 		c.Next()
 	}
 }
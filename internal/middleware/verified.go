@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VerificationChecker interface {
+	IsVerified(ctx context.Context, userID int64) (bool, error)
+}
+
+type VerifiedEmailMiddleware struct {
+	checker VerificationChecker
+}
+
+func NewVerifiedEmailMiddleware(checker VerificationChecker) *VerifiedEmailMiddleware {
+	return &VerifiedEmailMiddleware{
+		checker: checker,
+	}
+}
+
+func (m *VerifiedEmailMiddleware) VerifiedRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		verified, err := m.checker.IsVerified(c.Request.Context(), c.GetInt64("userID"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+
+		if !verified {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Please verify your email address before performing this action"})
+			return
+		}
+
+		c.Next()
+	}
+}
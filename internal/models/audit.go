@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type AuditAction string
+
+const (
+	AuditTeamJoinPolicyChanged       AuditAction = "team.join_policy_changed"
+	AuditTeamOwnershipTransferred    AuditAction = "team.ownership_transferred"
+	AuditTeamDeleted                 AuditAction = "team.deleted"
+	AuditTeamRestored                AuditAction = "team.restored"
+	AuditMemberInvited               AuditAction = "member.invited"
+	AuditInvitationResent            AuditAction = "invitation.resent"
+	AuditInvitationRevoked           AuditAction = "invitation.revoked"
+	AuditInviteLinkCreated           AuditAction = "invite_link.created"
+	AuditInviteLinkRevoked           AuditAction = "invite_link.revoked"
+	AuditJoinRequestApproved         AuditAction = "join_request.approved"
+	AuditJoinRequestRejected         AuditAction = "join_request.rejected"
+	AuditMemberJoined                AuditAction = "member.joined"
+	AuditMemberRoleChanged           AuditAction = "member.role_changed"
+	AuditMemberRemoved               AuditAction = "member.removed"
+	AuditMemberLeft                  AuditAction = "member.left"
+	AuditRoleCreated                 AuditAction = "role.created"
+	AuditRoleUpdated                 AuditAction = "role.updated"
+	AuditRoleDeleted                 AuditAction = "role.deleted"
+	AuditSubscriptionCreated         AuditAction = "subscription.created"
+	AuditSubscriptionUpdated         AuditAction = "subscription.updated"
+	AuditSubscriptionDeleted         AuditAction = "subscription.deleted"
+	AuditSubscriptionRestored        AuditAction = "subscription.restored"
+	AuditSubscriptionImported        AuditAction = "subscription.imported"
+	AuditSubscriptionChannelLinked   AuditAction = "subscription.channel_linked"
+	AuditSubscriptionChannelUnlinked AuditAction = "subscription.channel_unlinked"
+	AuditChannelCreated              AuditAction = "channel.created"
+	AuditChannelUpdated              AuditAction = "channel.updated"
+	AuditChannelDeleted              AuditAction = "channel.deleted"
+	AuditChannelRestored             AuditAction = "channel.restored"
+	AuditChannelImported             AuditAction = "channel.imported"
+)
+
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+type TeamAuditEvent struct {
+	bun.BaseModel `bun:"table:team_audit_events,alias:tae"`
+
+	ID           int64                  `bun:"id,pk,autoincrement" json:"id"`
+	TeamID       int64                  `bun:"team_id,notnull" json:"team_id"`
+	ActorID      int64                  `bun:"actor_id,notnull" json:"actor_id"`
+	Action       AuditAction            `bun:"action,notnull" json:"action"`
+	ResourceType string                 `bun:"resource_type,notnull" json:"resource_type"`
+	ResourceID   int64                  `bun:"resource_id" json:"resource_id,omitempty"`
+	Changes      map[string]AuditChange `bun:"changes,type:jsonb" json:"changes,omitempty"`
+	CreatedAt    time.Time              `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+
+	Actor *User `bun:"rel:belongs-to,join:actor_id=id" json:"actor,omitempty"`
+}
@@ -31,3 +31,39 @@ type EmailVerification struct {
 
 	User *User `bun:"rel:belongs-to,join:user_id=id" json:"-"`
 }
+
+type AuthAttempt struct {
+	bun.BaseModel `bun:"table:auth_attempts,alias:aa"`
+
+	ID        int64     `bun:"id,pk,autoincrement" json:"id"`
+	Action    string    `bun:"action,notnull" json:"action"`
+	Email     string    `bun:"email" json:"email,omitempty"`
+	IP        string    `bun:"ip" json:"ip"`
+	Success   bool      `bun:"success,notnull" json:"success"`
+	Reason    string    `bun:"reason" json:"reason,omitempty"`
+	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+}
+
+type AuthThrottle struct {
+	bun.BaseModel `bun:"table:auth_throttles,alias:ath"`
+
+	Key         string    `bun:"key,pk" json:"key"`
+	Failures    int       `bun:"failures,notnull,default:0" json:"failures"`
+	WindowStart time.Time `bun:"window_start,notnull" json:"window_start"`
+	LastFailure time.Time `bun:"last_failure,notnull" json:"last_failure"`
+	LockedUntil time.Time `bun:"locked_until,nullzero" json:"locked_until,omitempty"`
+}
+
+type EmailChange struct {
+	bun.BaseModel `bun:"table:email_changes,alias:ec"`
+
+	ID        int64     `bun:"id,pk,autoincrement" json:"-"`
+	UserID    int64     `bun:"user_id,notnull" json:"-"`
+	NewEmail  string    `bun:"new_email,notnull" json:"new_email"`
+	Token     string    `bun:"token,notnull,unique" json:"-"`
+	ExpiresAt time.Time `bun:"expires_at,notnull" json:"expires_at"`
+	Used      bool      `bun:"used,notnull,default:false" json:"-"`
+	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp" json:"-"`
+
+	User *User `bun:"rel:belongs-to,join:user_id=id" json:"-"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type DataExportStatus string
+
+const (
+	DataExportStatusPending    DataExportStatus = "pending"
+	DataExportStatusProcessing DataExportStatus = "processing"
+	DataExportStatusReady      DataExportStatus = "ready"
+	DataExportStatusFailed     DataExportStatus = "failed"
+)
+
+type DataExport struct {
+	bun.BaseModel `bun:"table:data_exports,alias:de"`
+
+	ID           int64            `bun:"id,pk,autoincrement" json:"id"`
+	UserID       int64            `bun:"user_id,notnull" json:"-"`
+	Status       DataExportStatus `bun:"status,notnull" json:"status"`
+	FilePath     string           `bun:"file_path" json:"-"`
+	ErrorMessage string           `bun:"error_message" json:"error_message,omitempty"`
+	CreatedAt    time.Time        `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+	CompletedAt  *time.Time       `bun:"completed_at" json:"completed_at,omitempty"`
+	ExpiresAt    *time.Time       `bun:"expires_at" json:"expires_at,omitempty"`
+}
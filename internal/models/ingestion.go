@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// IngestionCursor records how far an ingestion stream has been read, so
+// polling resumes where it stopped after a restart.
+type IngestionCursor struct {
+	bun.BaseModel `bun:"table:ingestion_cursors,alias:ic"`
+
+	Stream    string    `bun:"stream,pk" json:"stream"`
+	TxDigest  string    `bun:"tx_digest,notnull" json:"tx_digest"`
+	EventSeq  string    `bun:"event_seq,notnull" json:"event_seq"`
+	UpdatedAt time.Time `bun:"updated_at,notnull,default:current_timestamp" json:"updated_at"`
+}
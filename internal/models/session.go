@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type Session struct {
+	bun.BaseModel `bun:"table:sessions,alias:ses"`
+
+	ID         int64     `bun:"id,pk,autoincrement" json:"id"`
+	UserID     int64     `bun:"user_id,notnull" json:"-"`
+	UserAgent  string    `bun:"user_agent" json:"user_agent"`
+	IP         string    `bun:"ip" json:"ip"`
+	CreatedAt  time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+	LastSeenAt time.Time `bun:"last_seen_at,notnull,default:current_timestamp" json:"last_seen_at"`
+	ExpiresAt  time.Time `bun:"expires_at,notnull" json:"expires_at"`
+	RevokedAt  time.Time `bun:"revoked_at,nullzero" json:"-"`
+
+	Current bool `bun:"-" json:"current"`
+
+	User *User `bun:"rel:belongs-to,join:user_id=id" json:"-"`
+}
@@ -9,18 +9,92 @@ import (
 type Subscription struct {
 	bun.BaseModel `bun:"table:subscriptions,alias:s"`
 
-	ID          int64     `bun:"id,pk,autoincrement" json:"id"`
-	Name        string    `bun:"name,notnull" json:"name"`
-	Description string    `bun:"description" json:"description"`
-	EventType   string    `bun:"event_type,notnull" json:"event_type"`
-	TeamID      *int64    `bun:"team_id" json:"team_id,omitempty"`
-	UserID      int64     `bun:"user_id,notnull" json:"user_id"`
-	IsActive    bool      `bun:"is_active,notnull,default:true" json:"is_active"`
-	CreatedAt   time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
-	UpdatedAt   time.Time `bun:"updated_at,notnull,default:current_timestamp" json:"updated_at"`
-	DeletedAt   time.Time `bun:"deleted_at,soft_delete" json:"-"`
+	ID          int64            `bun:"id,pk,autoincrement" json:"id"`
+	Name        string           `bun:"name,notnull" json:"name"`
+	Description string           `bun:"description" json:"description"`
+	Kind        SubscriptionKind `bun:"kind,notnull,default:'event'" json:"kind"`
+	EventType   string           `bun:"event_type,notnull" json:"event_type,omitempty"`
+	EventFields []EventField     `bun:"event_fields,type:jsonb" json:"event_fields,omitempty"`
+	Filter      string           `bun:"filter" json:"filter,omitempty"`
+
+	Addresses        []string         `bun:"addresses,type:jsonb" json:"addresses,omitempty"`
+	AddressDirection AddressDirection `bun:"address_direction" json:"address_direction,omitempty"`
+
+	ObjectIDs         []string `bun:"object_ids,type:jsonb" json:"object_ids,omitempty"`
+	ObjectType        string   `bun:"object_type" json:"object_type,omitempty"`
+	ObjectChangeTypes []string `bun:"object_change_types,type:jsonb" json:"object_change_types,omitempty"`
+
+	BalanceAddress     string             `bun:"balance_address" json:"balance_address,omitempty"`
+	CoinType           string             `bun:"coin_type" json:"coin_type,omitempty"`
+	CoinDecimals       int                `bun:"coin_decimals" json:"coin_decimals,omitempty"`
+	Threshold          string             `bun:"threshold" json:"threshold,omitempty"`
+	ThresholdDirection ThresholdDirection `bun:"threshold_direction" json:"threshold_direction,omitempty"`
+	Hysteresis         string             `bun:"hysteresis" json:"hysteresis,omitempty"`
+	Balance            string             `bun:"balance" json:"balance,omitempty"`
+	Triggered          bool               `bun:"triggered,notnull,default:false" json:"triggered,omitempty"`
+
+	PackageID    string `bun:"package_id" json:"package_id,omitempty"`
+	UpgradeCapID string `bun:"upgrade_cap_id" json:"upgrade_cap_id,omitempty"`
+
+	SystemEvents []string `bun:"system_events,type:jsonb" json:"system_events,omitempty"`
+	Validators   []string `bun:"validators,type:jsonb" json:"validators,omitempty"`
+
+	Senders          []string  `bun:"senders,type:jsonb" json:"senders,omitempty"`
+	EmitterPackage   string    `bun:"emitter_package" json:"emitter_package,omitempty"`
+	EmitterModule    string    `bun:"emitter_module" json:"emitter_module,omitempty"`
+	TransactionKinds []string  `bun:"transaction_kinds,type:jsonb" json:"transaction_kinds,omitempty"`
+	TeamID           *int64    `bun:"team_id" json:"team_id,omitempty"`
+	UserID           int64     `bun:"user_id,notnull" json:"user_id"`
+	IsActive         bool      `bun:"is_active,notnull,default:true" json:"is_active"`
+	CreatedAt        time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt        time.Time `bun:"updated_at,notnull,default:current_timestamp" json:"updated_at"`
+	DeletedAt        time.Time `bun:"deleted_at,soft_delete" json:"-"`
 
 	Team     *Team                  `bun:"rel:belongs-to,join:team_id=id" json:"team,omitempty"`
 	User     *User                  `bun:"rel:belongs-to,join:user_id=id" json:"user,omitempty"`
 	Channels []*SubscriptionChannel `bun:"rel:has-many,join:id=subscription_id" json:"channels,omitempty"`
 }
+
+type SubscriptionKind string
+
+const (
+	// SubscriptionKindEvent delivers Move events matching an event type.
+	SubscriptionKindEvent SubscriptionKind = "event"
+	// SubscriptionKindAddress delivers the transactions that touch a set of
+	// addresses.
+	SubscriptionKindAddress SubscriptionKind = "address"
+	// SubscriptionKindObject delivers the changes to specific objects, or to
+	// objects of a type.
+	SubscriptionKindObject SubscriptionKind = "object"
+	// SubscriptionKindBalance alerts when an address's balance of a coin
+	// crosses a threshold.
+	SubscriptionKindBalance SubscriptionKind = "balance"
+	// SubscriptionKindPackage delivers the upgrades of a Move package and
+	// changes to the UpgradeCap controlling them.
+	SubscriptionKindPackage SubscriptionKind = "package"
+	// SubscriptionKindSystem delivers epoch changes and stake movements of
+	// the Sui system.
+	SubscriptionKindSystem SubscriptionKind = "system"
+)
+
+type AddressDirection string
+
+const (
+	AddressDirectionFrom AddressDirection = "from"
+	AddressDirectionTo   AddressDirection = "to"
+	AddressDirectionAny  AddressDirection = "any"
+)
+
+type ThresholdDirection string
+
+const (
+	ThresholdBelow ThresholdDirection = "below"
+	ThresholdAbove ThresholdDirection = "above"
+)
+
+// EventField is one field of the event struct a subscription listens to, as
+// published on chain.
+type EventField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
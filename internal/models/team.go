@@ -9,18 +9,37 @@ import (
 type Team struct {
 	bun.BaseModel `bun:"table:teams,alias:t"`
 
-	ID          int64     `bun:"id,pk,autoincrement" json:"id"`
-	Name        string    `bun:"name,notnull" json:"name"`
-	Description string    `bun:"description" json:"description"`
-	OwnerID     int64     `bun:"owner_id,notnull" json:"owner_id"`
-	CreatedAt   time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
-	UpdatedAt   time.Time `bun:"updated_at,notnull,default:current_timestamp" json:"updated_at"`
-	DeletedAt   time.Time `bun:"deleted_at,soft_delete" json:"-"`
+	ID             int64          `bun:"id,pk,autoincrement" json:"id"`
+	Name           string         `bun:"name,notnull" json:"name"`
+	Description    string         `bun:"description" json:"description"`
+	OwnerID        int64          `bun:"owner_id,notnull" json:"owner_id"`
+	JoinPolicy     TeamJoinPolicy `bun:"join_policy,notnull,default:'invite_only'" json:"join_policy"`
+	AllowedDomains []string       `bun:"allowed_domains,array" json:"allowed_domains,omitempty"`
+	Plan           TeamPlan       `bun:"plan,notnull,default:'free'" json:"plan"`
+	CreatedAt      time.Time      `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt      time.Time      `bun:"updated_at,notnull,default:current_timestamp" json:"updated_at"`
+	DeletedAt      time.Time      `bun:"deleted_at,soft_delete" json:"-"`
 
 	Owner   *User             `bun:"rel:belongs-to,join:owner_id=id" json:"owner,omitempty"`
 	Members []*TeamMembership `bun:"rel:has-many,join:id=team_id" json:"members,omitempty"`
 }
 
+type TeamJoinPolicy string
+
+const (
+	TeamJoinPolicyInviteOnly TeamJoinPolicy = "invite_only"
+	TeamJoinPolicyRequest    TeamJoinPolicy = "request"
+	TeamJoinPolicyDomain     TeamJoinPolicy = "domain"
+)
+
+type TeamPlan string
+
+const (
+	TeamPlanFree       TeamPlan = "free"
+	TeamPlanPro        TeamPlan = "pro"
+	TeamPlanEnterprise TeamPlan = "enterprise"
+)
+
 type TeamRole string
 
 const (
@@ -32,14 +51,117 @@ const (
 type TeamMembership struct {
 	bun.BaseModel `bun:"table:team_memberships,alias:tm"`
 
+	ID           int64     `bun:"id,pk,autoincrement" json:"id"`
+	TeamID       int64     `bun:"team_id,notnull" json:"team_id"`
+	UserID       int64     `bun:"user_id,notnull" json:"user_id"`
+	Role         TeamRole  `bun:"role,notnull" json:"role"`
+	CustomRoleID *int64    `bun:"custom_role_id" json:"custom_role_id,omitempty"`
+	CreatedAt    time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt    time.Time `bun:"updated_at,notnull,default:current_timestamp" json:"updated_at"`
+	DeletedAt    time.Time `bun:"deleted_at,soft_delete" json:"-"`
+
+	Team       *Team               `bun:"rel:belongs-to,join:team_id=id" json:"team,omitempty"`
+	User       *User               `bun:"rel:belongs-to,join:user_id=id" json:"user,omitempty"`
+	CustomRole *TeamRoleDefinition `bun:"rel:belongs-to,join:custom_role_id=id" json:"custom_role,omitempty"`
+}
+
+type TeamRoleDefinition struct {
+	bun.BaseModel `bun:"table:team_roles,alias:tr"`
+
+	ID          int64     `bun:"id,pk,autoincrement" json:"id"`
+	TeamID      int64     `bun:"team_id,notnull,unique:team_role_name" json:"team_id"`
+	Name        string    `bun:"name,notnull,unique:team_role_name" json:"name"`
+	Description string    `bun:"description" json:"description"`
+	Permissions []string  `bun:"permissions,array" json:"permissions"`
+	CreatedAt   time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt   time.Time `bun:"updated_at,notnull,default:current_timestamp" json:"updated_at"`
+}
+
+type TeamInvitationStatus string
+
+const (
+	TeamInvitationStatusPending  TeamInvitationStatus = "pending"
+	TeamInvitationStatusAccepted TeamInvitationStatus = "accepted"
+	TeamInvitationStatusDeclined TeamInvitationStatus = "declined"
+	TeamInvitationStatusRevoked  TeamInvitationStatus = "revoked"
+	TeamInvitationStatusExpired  TeamInvitationStatus = "expired"
+)
+
+type TeamInvitation struct {
+	bun.BaseModel `bun:"table:team_invitations,alias:ti"`
+
+	ID          int64                `bun:"id,pk,autoincrement" json:"id"`
+	TeamID      int64                `bun:"team_id,notnull" json:"team_id"`
+	Email       string               `bun:"email,notnull" json:"email"`
+	Role        TeamRole             `bun:"role,notnull" json:"role"`
+	Nonce       string               `bun:"nonce,notnull" json:"-"`
+	Status      TeamInvitationStatus `bun:"status,notnull" json:"status"`
+	InvitedByID int64                `bun:"invited_by_id,notnull" json:"invited_by_id"`
+	ExpiresAt   time.Time            `bun:"expires_at,notnull" json:"expires_at"`
+	RespondedAt *time.Time           `bun:"responded_at" json:"responded_at,omitempty"`
+	CreatedAt   time.Time            `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt   time.Time            `bun:"updated_at,notnull,default:current_timestamp" json:"updated_at"`
+
+	Token string `bun:"-" json:"token,omitempty"`
+
+	Team      *Team `bun:"rel:belongs-to,join:team_id=id" json:"team,omitempty"`
+	InvitedBy *User `bun:"rel:belongs-to,join:invited_by_id=id" json:"invited_by,omitempty"`
+}
+
+type TeamJoinRequestStatus string
+
+const (
+	TeamJoinRequestStatusPending  TeamJoinRequestStatus = "pending"
+	TeamJoinRequestStatusApproved TeamJoinRequestStatus = "approved"
+	TeamJoinRequestStatusRejected TeamJoinRequestStatus = "rejected"
+)
+
+type TeamJoinRequest struct {
+	bun.BaseModel `bun:"table:team_join_requests,alias:tjr"`
+
+	ID           int64                 `bun:"id,pk,autoincrement" json:"id"`
+	TeamID       int64                 `bun:"team_id,notnull" json:"team_id"`
+	UserID       int64                 `bun:"user_id,notnull" json:"user_id"`
+	Message      string                `bun:"message" json:"message,omitempty"`
+	Status       TeamJoinRequestStatus `bun:"status,notnull" json:"status"`
+	ReviewedByID *int64                `bun:"reviewed_by_id" json:"reviewed_by_id,omitempty"`
+	ReviewedAt   *time.Time            `bun:"reviewed_at" json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time             `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt    time.Time             `bun:"updated_at,notnull,default:current_timestamp" json:"updated_at"`
+
+	Team *Team `bun:"rel:belongs-to,join:team_id=id" json:"team,omitempty"`
+	User *User `bun:"rel:belongs-to,join:user_id=id" json:"user,omitempty"`
+}
+
+type TeamInviteLink struct {
+	bun.BaseModel `bun:"table:team_invite_links,alias:til"`
+
+	ID          int64      `bun:"id,pk,autoincrement" json:"id"`
+	TeamID      int64      `bun:"team_id,notnull" json:"team_id"`
+	Role        TeamRole   `bun:"role,notnull" json:"role"`
+	Nonce       string     `bun:"nonce,notnull" json:"-"`
+	MaxUses     int        `bun:"max_uses,notnull,default:0" json:"max_uses"`
+	UseCount    int        `bun:"use_count,notnull,default:0" json:"use_count"`
+	ExpiresAt   *time.Time `bun:"expires_at" json:"expires_at,omitempty"`
+	RevokedAt   *time.Time `bun:"revoked_at" json:"revoked_at,omitempty"`
+	CreatedByID int64      `bun:"created_by_id,notnull" json:"created_by_id"`
+	CreatedAt   time.Time  `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt   time.Time  `bun:"updated_at,notnull,default:current_timestamp" json:"updated_at"`
+
+	Token string `bun:"-" json:"token,omitempty"`
+
+	Team *Team `bun:"rel:belongs-to,join:team_id=id" json:"team,omitempty"`
+}
+
+type TeamInviteLinkRedemption struct {
+	bun.BaseModel `bun:"table:team_invite_link_redemptions,alias:tilr"`
+
 	ID        int64     `bun:"id,pk,autoincrement" json:"id"`
+	LinkID    int64     `bun:"link_id,notnull" json:"link_id"`
 	TeamID    int64     `bun:"team_id,notnull" json:"team_id"`
 	UserID    int64     `bun:"user_id,notnull" json:"user_id"`
-	Role      TeamRole  `bun:"role,notnull" json:"role"`
+	IP        string    `bun:"ip" json:"ip"`
 	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
-	UpdatedAt time.Time `bun:"updated_at,notnull,default:current_timestamp" json:"updated_at"`
-	DeletedAt time.Time `bun:"deleted_at,soft_delete" json:"-"`
 
-	Team *Team `bun:"rel:belongs-to,join:team_id=id" json:"team,omitempty"`
 	User *User `bun:"rel:belongs-to,join:user_id=id" json:"user,omitempty"`
 }
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// TeamUsage is the monthly rollup of metered usage for a team. Period is the
+// first day of the month in UTC.
+type TeamUsage struct {
+	bun.BaseModel `bun:"table:team_usage,alias:tu"`
+
+	ID            int64     `bun:"id,pk,autoincrement" json:"-"`
+	TeamID        int64     `bun:"team_id,notnull,unique:team_usage_period" json:"team_id"`
+	Period        time.Time `bun:"period,type:date,notnull,unique:team_usage_period" json:"period"`
+	Notifications int64     `bun:"notifications,notnull,default:0" json:"notifications"`
+	UpdatedAt     time.Time `bun:"updated_at,notnull,default:current_timestamp" json:"updated_at"`
+}
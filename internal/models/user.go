@@ -18,4 +18,4 @@ type User struct {
 	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
 	UpdatedAt time.Time `bun:"updated_at,notnull,default:current_timestamp" json:"updated_at"`
 	DeletedAt time.Time `bun:"deleted_at,soft_delete" json:"-"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,164 @@
+package move
+
+type PatternKind string
+
+const (
+	// PatternPackage matches every event emitted by a package's modules.
+	PatternPackage PatternKind = "package"
+	// PatternModule matches every event defined in one module.
+	PatternModule PatternKind = "module"
+	// PatternStruct matches a struct whatever its type arguments.
+	PatternStruct PatternKind = "struct"
+	// PatternType matches a struct with the given type arguments, where any
+	// argument may be a * wildcard.
+	PatternType PatternKind = "type"
+)
+
+// EventPattern selects the events a subscription receives. It is written like
+// a struct tag that may stop early or use wildcards:
+//
+//	0xdee9::*                               every event from package 0xdee9
+//	0xdee9::clob::*                         every event from module clob
+//	0xdee9::clob::OrderFilled               OrderFilled for any coin types
+//	0xdee9::clob::OrderFilled<*, 0x2::sui::SUI>
+//
+// The ::* suffix may be left out.
+type EventPattern struct {
+	Address    string
+	Module     string
+	Name       string
+	TypeParams []TypeTag
+}
+
+// ParseEventPattern parses an event pattern and normalizes its addresses.
+func ParseEventPattern(input string) (*EventPattern, error) {
+	p := &parser{input: input, wildcards: true}
+	p.skipSpace()
+
+	start := p.pos
+	address := p.word()
+	if address == "" {
+		return nil, p.unexpected("an address")
+	}
+
+	if err := checkAddress(address); err != nil {
+		return nil, p.addressErrorAt(start, err)
+	}
+
+	pattern := &EventPattern{Address: normalizeAddress(address)}
+	if p.consume("::*") || p.atEnd() {
+		return pattern, p.expectEnd()
+	}
+
+	var err error
+	if pattern.Module, err = p.qualifiedPart("module"); err != nil {
+		return nil, err
+	}
+
+	if p.consume("::*") || p.atEnd() {
+		return pattern, p.expectEnd()
+	}
+
+	tag := &StructTag{Address: pattern.Address, Module: pattern.Module}
+	if tag.Name, err = p.qualifiedPart("struct"); err != nil {
+		return nil, err
+	}
+
+	if err := p.parseTypeParams(tag, 0); err != nil {
+		return nil, err
+	}
+
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+
+	pattern.Name = tag.Name
+	pattern.TypeParams = tag.TypeParams
+	return pattern, nil
+}
+
+func (p EventPattern) Kind() PatternKind {
+	switch {
+	case p.Module == "":
+		return PatternPackage
+	case p.Name == "":
+		return PatternModule
+	case p.TypeParams == nil:
+		return PatternStruct
+	default:
+		return PatternType
+	}
+}
+
+// String renders the pattern in canonical form.
+func (p EventPattern) String() string {
+	switch p.Kind() {
+	case PatternPackage:
+		return p.Address + "::*"
+	case PatternModule:
+		return p.Address + "::" + p.Module + "::*"
+	default:
+		return p.StructTag().String()
+	}
+}
+
+// StructTag returns the struct the pattern names, or nil for package and
+// module patterns. Wildcard type arguments are kept as they are.
+func (p EventPattern) StructTag() *StructTag {
+	if p.Name == "" {
+		return nil
+	}
+	return &StructTag{Address: p.Address, Module: p.Module, Name: p.Name, TypeParams: p.TypeParams}
+}
+
+// Matches reports whether an emitted event type is selected by the pattern.
+func (p EventPattern) Matches(tag *StructTag) bool {
+	if tag.Address != p.Address {
+		return false
+	}
+
+	if p.Module != "" && tag.Module != p.Module {
+		return false
+	}
+
+	if p.Name != "" && tag.Name != p.Name {
+		return false
+	}
+
+	if p.TypeParams == nil {
+		return true
+	}
+
+	return typeParamsMatch(p.TypeParams, tag.TypeParams)
+}
+
+func typeParamsMatch(pattern, actual []TypeTag) bool {
+	if len(pattern) != len(actual) {
+		return false
+	}
+
+	for i := range pattern {
+		if !typeMatches(pattern[i], actual[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func typeMatches(pattern, actual TypeTag) bool {
+	switch {
+	case pattern.Wildcard:
+		return true
+	case pattern.Vector != nil:
+		return actual.Vector != nil && typeMatches(*pattern.Vector, *actual.Vector)
+	case pattern.Struct != nil:
+		return actual.Struct != nil &&
+			pattern.Struct.Address == actual.Struct.Address &&
+			pattern.Struct.Module == actual.Struct.Module &&
+			pattern.Struct.Name == actual.Struct.Name &&
+			typeParamsMatch(pattern.Struct.TypeParams, actual.Struct.TypeParams)
+	default:
+		return actual.Primitive != "" && pattern.Primitive == actual.Primitive
+	}
+}
@@ -0,0 +1,122 @@
+package move
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseEventPattern(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  PatternKind
+		want  string
+	}{
+		{"0x2", PatternPackage, address2 + "::*"},
+		{"0x2::*", PatternPackage, address2 + "::*"},
+		{" 0x2::* ", PatternPackage, address2 + "::*"},
+		{"0x2::coin", PatternModule, address2 + "::coin::*"},
+		{"0x2::coin::*", PatternModule, address2 + "::coin::*"},
+		{"0x2::coin::CoinCreated", PatternStruct, address2 + "::coin::CoinCreated"},
+		{"0x2::coin::Coin<0x2::sui::SUI>", PatternType, address2 + "::coin::Coin<" + address2 + "::sui::SUI>"},
+		{"0x2::pool::Swap<*, 0x2::sui::SUI>", PatternType, address2 + "::pool::Swap<*, " + address2 + "::sui::SUI>"},
+		{"0x2::m::S<vector<*>>", PatternType, address2 + "::m::S<vector<*>>"},
+		{"0x2::m::S<0x1::m::T<*>>", PatternType, address2 + "::m::S<" + address1 + "::m::T<*>>"},
+	}
+
+	for _, tt := range tests {
+		pattern, err := ParseEventPattern(tt.input)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.input, err)
+			continue
+		}
+
+		if kind := pattern.Kind(); kind != tt.kind {
+			t.Errorf("%q: kind %s, want %s", tt.input, kind, tt.kind)
+		}
+
+		if got := pattern.String(); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseEventPatternErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		position int
+		message  string
+	}{
+		{"", 1, "expected an address, found end of input"},
+		{"*", 1, "expected an address, found '*'"},
+		{"coin", 1, `address "coin" must start with 0x`},
+		{"0xZZ::coin", 3, `address "0xZZ" contains non-hex character 'Z'`},
+		{"0x2::", 6, "expected a module name, found end of input"},
+		{"0x2::*::Coin", 7, "expected end of input, found ':'"},
+		{"0x2::coin::*::x", 13, "expected end of input, found ':'"},
+		{"0x2::coin::Coin<", 17, "expected a type, found end of input"},
+		{"0x2::coin::Coin<*", 18, `expected ">", found end of input`},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseEventPattern(tt.input)
+
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("%q: got %v, want a syntax error", tt.input, err)
+			continue
+		}
+
+		if syntaxErr.Position != tt.position {
+			t.Errorf("%q: position %d, want %d (%v)", tt.input, syntaxErr.Position, tt.position, err)
+		}
+
+		if !strings.Contains(syntaxErr.Message, tt.message) {
+			t.Errorf("%q: message %q, want it to contain %q", tt.input, syntaxErr.Message, tt.message)
+		}
+	}
+}
+
+func TestEventPatternMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		event   string
+		want    bool
+	}{
+		{"0x2", "0x2::coin::CoinCreated", true},
+		{"0x2", "0x3::coin::CoinCreated", false},
+		{"0x2::coin", "0x2::coin::CoinCreated<0x2::sui::SUI>", true},
+		{"0x2::coin", "0x2::balance::Supply", false},
+		{"0x2::coin::CoinCreated", "0x2::coin::CoinCreated", true},
+		{"0x2::coin::CoinCreated", "0x2::coin::CoinCreated<0x2::sui::SUI>", true},
+		{"0x2::coin::CoinCreated", "0x2::coin::CoinDestroyed", false},
+		{"0x2::coin::Coin<0x2::sui::SUI>", "0x2::coin::Coin<0x2::sui::SUI>", true},
+		{"0x2::coin::Coin<0x2::sui::SUI>", "0x2::coin::Coin<0x3::usdc::USDC>", false},
+		{"0x2::coin::Coin<0x2::sui::SUI>", "0x2::coin::Coin", false},
+		{"0x2::pool::Swap<*, 0x2::sui::SUI>", "0x2::pool::Swap<0x3::usdc::USDC, 0x2::sui::SUI>", true},
+		{"0x2::pool::Swap<*, 0x2::sui::SUI>", "0x2::pool::Swap<0x2::sui::SUI, 0x3::usdc::USDC>", false},
+		{"0x2::pool::Swap<*>", "0x2::pool::Swap<u8, u8>", false},
+		{"0x2::m::S<vector<*>>", "0x2::m::S<vector<u8>>", true},
+		{"0x2::m::S<vector<*>>", "0x2::m::S<u8>", false},
+		{"0x2::m::S<u64>", "0x2::m::S<u8>", false},
+		{"0x2::m::S<0x1::m::T<*>>", "0x2::m::S<0x1::m::T<bool>>", true},
+		{"0x2::m::S<0x1::m::T<*>>", "0x2::m::S<0x1::m::U<bool>>", false},
+		{"0x2::m::S<*>", "0x2::m::S<vector<0x1::m::T<bool>>>", true},
+	}
+
+	for _, tt := range tests {
+		pattern, err := ParseEventPattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.pattern, err)
+		}
+
+		tag, err := ParseStructTag(tt.event)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.event, err)
+		}
+
+		if got := pattern.Matches(tag); got != tt.want {
+			t.Errorf("%s matching %s: got %v, want %v", tt.pattern, tt.event, got, tt.want)
+		}
+	}
+}
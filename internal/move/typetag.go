@@ -0,0 +1,384 @@
+package move
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AddressLength is the number of hex digits in a full Sui address.
+const AddressLength = 64
+
+// maxTypeDepth bounds how deeply type arguments may nest, so a hostile input
+// cannot recurse the parser without limit.
+const maxTypeDepth = 16
+
+var primitives = map[string]bool{
+	"bool":    true,
+	"u8":      true,
+	"u16":     true,
+	"u32":     true,
+	"u64":     true,
+	"u128":    true,
+	"u256":    true,
+	"address": true,
+	"signer":  true,
+}
+
+// TypeTag is a Move type: a primitive, a vector or a struct. Exactly one of
+// Primitive, Vector and Struct is set, unless the tag is a wildcard from an
+// EventPattern.
+type TypeTag struct {
+	Primitive string
+	Vector    *TypeTag
+	Struct    *StructTag
+	Wildcard  bool
+}
+
+// StructTag is a fully qualified struct type such as
+// 0x2::coin::Coin<0x2::sui::SUI>, with its address normalized.
+type StructTag struct {
+	Address    string
+	Module     string
+	Name       string
+	TypeParams []TypeTag
+}
+
+func (t TypeTag) String() string {
+	switch {
+	case t.Wildcard:
+		return "*"
+	case t.Vector != nil:
+		return "vector<" + t.Vector.String() + ">"
+	case t.Struct != nil:
+		return t.Struct.String()
+	default:
+		return t.Primitive
+	}
+}
+
+// String renders the tag in canonical form: full-length lowercase addresses
+// and type arguments separated by ", ".
+func (t StructTag) String() string {
+	var b strings.Builder
+	b.WriteString(t.Address)
+	b.WriteString("::")
+	b.WriteString(t.Module)
+	b.WriteString("::")
+	b.WriteString(t.Name)
+
+	if len(t.TypeParams) > 0 {
+		b.WriteString("<")
+		for i, param := range t.TypeParams {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(param.String())
+		}
+		b.WriteString(">")
+	}
+
+	return b.String()
+}
+
+// SyntaxError reports where in the input a type tag stopped making sense.
+// Position is 1-based and counts bytes.
+type SyntaxError struct {
+	Position int
+	Message  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Position)
+}
+
+// ParseStructTag parses a struct type tag such as
+// 0x2::coin::CoinCreated<0x2::sui::SUI>.
+func ParseStructTag(input string) (*StructTag, error) {
+	p := &parser{input: input}
+	p.skipSpace()
+	start := p.pos
+
+	tag, err := p.parseType(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if tag.Struct == nil {
+		return nil, p.errorAt(start, "expected a struct type, found %q", tag.String())
+	}
+
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+
+	return tag.Struct, nil
+}
+
+// ParseTypeTag parses any Move type, including primitives and vectors.
+func ParseTypeTag(input string) (*TypeTag, error) {
+	p := &parser{input: input}
+	p.skipSpace()
+
+	tag, err := p.parseType(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+// NormalizeAddress turns a 0x-prefixed hex address of up to 64 digits into its
+// full-length lowercase form, so 0x2 and 0x0…02 compare equal.
+func NormalizeAddress(address string) (string, error) {
+	if err := checkAddress(address); err != nil {
+		return "", err
+	}
+	return normalizeAddress(address), nil
+}
+
+func normalizeAddress(address string) string {
+	digits := strings.ToLower(address[2:])
+	return "0x" + strings.Repeat("0", AddressLength-len(digits)) + digits
+}
+
+// addressError says what is wrong with an address and at which byte of it, so
+// the parsers can point at the offending character.
+type addressError struct {
+	offset  int
+	message string
+}
+
+func (e *addressError) Error() string {
+	return e.message
+}
+
+func checkAddress(address string) error {
+	if !strings.HasPrefix(address, "0x") && !strings.HasPrefix(address, "0X") {
+		return &addressError{0, fmt.Sprintf("address %q must start with 0x", address)}
+	}
+
+	digits := address[2:]
+	if digits == "" {
+		return &addressError{2, fmt.Sprintf("address %q has no hex digits", address)}
+	}
+
+	if len(digits) > AddressLength {
+		return &addressError{2 + AddressLength, fmt.Sprintf("address %q is longer than %d hex digits", address, AddressLength)}
+	}
+
+	for i, r := range digits {
+		if !isHexDigit(r) {
+			return &addressError{2 + i, fmt.Sprintf("address %q contains non-hex character %q", address, r)}
+		}
+	}
+
+	return nil
+}
+
+// addressErrorAt reports an invalid address that starts at start.
+func (p *parser) addressErrorAt(start int, err error) error {
+	if problem, ok := err.(*addressError); ok {
+		return p.errorAt(start+problem.offset, "%s", problem.message)
+	}
+	return p.errorAt(start, "%s", err.Error())
+}
+
+type parser struct {
+	input     string
+	pos       int
+	wildcards bool
+}
+
+func (p *parser) parseType(depth int) (*TypeTag, error) {
+	if depth > maxTypeDepth {
+		return nil, p.errorAt(p.pos, "type arguments nest deeper than %d levels", maxTypeDepth)
+	}
+
+	start := p.pos
+	if p.wildcards && p.consume("*") {
+		p.skipSpace()
+		return &TypeTag{Wildcard: true}, nil
+	}
+
+	word := p.word()
+	if word == "" {
+		return nil, p.unexpected("a type")
+	}
+
+	if strings.HasPrefix(word, "0x") || strings.HasPrefix(word, "0X") || isDigit(rune(word[0])) {
+		if err := checkAddress(word); err != nil {
+			return nil, p.addressErrorAt(start, err)
+		}
+
+		tag, err := p.parseStruct(normalizeAddress(word), depth)
+		if err != nil {
+			return nil, err
+		}
+		return &TypeTag{Struct: tag}, nil
+	}
+
+	if word == "vector" {
+		p.skipSpace()
+		if err := p.expect("<"); err != nil {
+			return nil, err
+		}
+
+		elem, err := p.parseType(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(">"); err != nil {
+			return nil, err
+		}
+		return &TypeTag{Vector: elem}, nil
+	}
+
+	if primitives[word] {
+		p.skipSpace()
+		return &TypeTag{Primitive: word}, nil
+	}
+
+	return nil, p.errorAt(start, "unknown type %q", word)
+}
+
+func (p *parser) parseStruct(address string, depth int) (*StructTag, error) {
+	tag := &StructTag{Address: address}
+
+	var err error
+	if tag.Module, err = p.qualifiedPart("module"); err != nil {
+		return nil, err
+	}
+
+	if tag.Name, err = p.qualifiedPart("struct"); err != nil {
+		return nil, err
+	}
+
+	if err := p.parseTypeParams(tag, depth); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (p *parser) parseTypeParams(tag *StructTag, depth int) error {
+	p.skipSpace()
+	if !p.consume("<") {
+		return nil
+	}
+
+	for {
+		p.skipSpace()
+		param, err := p.parseType(depth + 1)
+		if err != nil {
+			return err
+		}
+		tag.TypeParams = append(tag.TypeParams, *param)
+
+		if p.consume(",") {
+			continue
+		}
+
+		return p.expect(">")
+	}
+}
+
+// qualifiedPart reads "::" followed by a Move identifier.
+func (p *parser) qualifiedPart(kind string) (string, error) {
+	if !p.consume("::") {
+		return "", p.unexpected(`"::"`)
+	}
+
+	start := p.pos
+	name := p.word()
+	if name == "" {
+		return "", p.unexpected("a " + kind + " name")
+	}
+
+	if !isIdentifier(name) {
+		return "", p.errorAt(start, "invalid %s name %q", kind, name)
+	}
+
+	return name, nil
+}
+
+func (p *parser) word() string {
+	start := p.pos
+	for p.pos < len(p.input) {
+		r := rune(p.input[p.pos])
+		if !isDigit(r) && !isLetter(r) && r != '_' {
+			break
+		}
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) consume(token string) bool {
+	if strings.HasPrefix(p.input[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(token string) error {
+	p.skipSpace()
+	if !p.consume(token) {
+		return p.unexpected(fmt.Sprintf("%q", token))
+	}
+	p.skipSpace()
+	return nil
+}
+
+func (p *parser) atEnd() bool {
+	p.skipSpace()
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) expectEnd() error {
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return p.unexpected("end of input")
+	}
+	return nil
+}
+
+func (p *parser) unexpected(want string) error {
+	if p.pos >= len(p.input) {
+		return p.errorAt(p.pos, "expected %s, found end of input", want)
+	}
+	return p.errorAt(p.pos, "expected %s, found %q", want, p.input[p.pos])
+}
+
+func (p *parser) errorAt(pos int, format string, args ...interface{}) error {
+	return &SyntaxError{Position: pos + 1, Message: fmt.Sprintf(format, args...)}
+}
+
+func isIdentifier(name string) bool {
+	if name == "_" || isDigit(rune(name[0])) {
+		return false
+	}
+	return true
+}
+
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isHexDigit(r rune) bool {
+	return isDigit(r) || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+}
@@ -0,0 +1,182 @@
+package move
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	address1 = "0x0000000000000000000000000000000000000000000000000000000000000001"
+	address2 = "0x0000000000000000000000000000000000000000000000000000000000000002"
+)
+
+func TestParseStructTag(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"0x2::coin::CoinCreated", address2 + "::coin::CoinCreated"},
+		{"0x2::coin::Coin<0x2::sui::SUI>", address2 + "::coin::Coin<" + address2 + "::sui::SUI>"},
+		{"  0x2::coin::Coin < 0x2::sui::SUI >  ", address2 + "::coin::Coin<" + address2 + "::sui::SUI>"},
+		{"0X2::m::S", address2 + "::m::S"},
+		{"0x000002::m::S", address2 + "::m::S"},
+		{"0x2::m::S<u8,vector<address>, 0x1::string::String>", address2 + "::m::S<u8, vector<address>, " + address1 + "::string::String>"},
+		{"0x2::m::S<vector<vector<0x1::m::T<bool>>>>", address2 + "::m::S<vector<vector<" + address1 + "::m::T<bool>>>>"},
+		{"0xABcd::Mod_1::_Struct", "0x" + strings.Repeat("0", 60) + "abcd::Mod_1::_Struct"},
+	}
+
+	for _, tt := range tests {
+		tag, err := ParseStructTag(tt.input)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.input, err)
+			continue
+		}
+
+		if got := tag.String(); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseStructTagErrorPosition(t *testing.T) {
+	tests := []struct {
+		input    string
+		position int
+		message  string
+	}{
+		{"", 1, "expected a type, found end of input"},
+		{"u64", 1, `expected a struct type, found "u64"`},
+		{"vector<u8>", 1, `expected a struct type, found "vector<u8>"`},
+		{"2::m::S", 1, `address "2" must start with 0x`},
+		{"0x::m::S", 3, `address "0x" has no hex digits`},
+		{"0xZZ::a::B", 3, `address "0xZZ" contains non-hex character 'Z'`},
+		{"0x2G::a::B", 4, `address "0x2G" contains non-hex character 'G'`},
+		{"  0xZZ::a::B", 5, `address "0xZZ" contains non-hex character 'Z'`},
+		{"0x" + strings.Repeat("a", 65) + "::m::S", 67, "longer than 64 hex digits"},
+		{"0x2", 4, `expected "::", found end of input`},
+		{"0x2::coin", 10, `expected "::", found end of input`},
+		{"0x2::coin::", 12, "expected a struct name, found end of input"},
+		{"0x2::1coin::Coin", 6, `invalid module name "1coin"`},
+		{"0x2::coin::_", 12, `invalid struct name "_"`},
+		{"0x2::coin:Coin", 10, `expected "::", found ':'`},
+		{"0x2::coin::Coin<", 17, "expected a type, found end of input"},
+		{"0x2::coin::Coin<>", 17, `expected a type, found '>'`},
+		{"0x2::coin::Coin<0x2::sui::SUI", 30, `expected ">", found end of input`},
+		{"0x2::coin::Coin<u9>", 17, `unknown type "u9"`},
+		{"0x2::coin::Coin<*>", 17, `expected a type, found '*'`},
+		{"0x2::a::B extra", 11, "expected end of input, found 'e'"},
+		{"0x2::a::B>", 10, "expected end of input, found '>'"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseStructTag(tt.input)
+
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("%q: got %v, want a syntax error", tt.input, err)
+			continue
+		}
+
+		if syntaxErr.Position != tt.position {
+			t.Errorf("%q: position %d, want %d (%v)", tt.input, syntaxErr.Position, tt.position, err)
+		}
+
+		if !strings.Contains(syntaxErr.Message, tt.message) {
+			t.Errorf("%q: message %q, want it to contain %q", tt.input, syntaxErr.Message, tt.message)
+		}
+	}
+}
+
+func TestParseTypeTag(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		err   bool
+	}{
+		{"u8", "u8", false},
+		{"u256", "u256", false},
+		{"address", "address", false},
+		{"vector< u64 >", "vector<u64>", false},
+		{"vector<0x2::sui::SUI>", "vector<" + address2 + "::sui::SUI>", false},
+		{"vector", "", true},
+		{"vector<u8", "", true},
+		{"string", "", true},
+	}
+
+	for _, tt := range tests {
+		tag, err := ParseTypeTag(tt.input)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%q: got %s, want an error", tt.input, tag)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.input, err)
+			continue
+		}
+
+		if got := tag.String(); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseTypeTagDepth(t *testing.T) {
+	nested := func(depth int) string {
+		return strings.Repeat("vector<", depth) + "u8" + strings.Repeat(">", depth)
+	}
+
+	if _, err := ParseTypeTag(nested(maxTypeDepth)); err != nil {
+		t.Errorf("%d levels: unexpected error: %v", maxTypeDepth, err)
+	}
+
+	_, err := ParseTypeTag(nested(maxTypeDepth + 1))
+
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("%d levels: got %v, want a syntax error", maxTypeDepth+1, err)
+	}
+
+	if want := (maxTypeDepth+1)*len("vector<") + 1; syntaxErr.Position != want {
+		t.Errorf("%d levels: position %d, want %d", maxTypeDepth+1, syntaxErr.Position, want)
+	}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		err   bool
+	}{
+		{"0x2", address2, false},
+		{"0X02", address2, false},
+		{address2, address2, false},
+		{"0xABC", "0x" + strings.Repeat("0", 61) + "abc", false},
+		{"2", "", true},
+		{"0x", "", true},
+		{"0xg", "", true},
+		{"0x" + strings.Repeat("1", 65), "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeAddress(tt.input)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%q: got %s, want an error", tt.input, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.input, err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/move"
+	"github.com/open-move/intercord/internal/sui"
+)
+
+// AddressActivity is the notification payload of an address subscription: one
+// transaction that touched a watched address.
+type AddressActivity struct {
+	Digest         string                 `json:"digest"`
+	Sender         string                 `json:"sender"`
+	Kind           string                 `json:"kind"`
+	Status         string                 `json:"status"`
+	Error          string                 `json:"error,omitempty"`
+	Checkpoint     string                 `json:"checkpoint,omitempty"`
+	Timestamp      *time.Time             `json:"timestamp,omitempty"`
+	GasUsed        *sui.GasCostSummary    `json:"gas_used,omitempty"`
+	Addresses      []string               `json:"addresses"`
+	BalanceChanges []ActivityBalance      `json:"balance_changes"`
+	ObjectChanges  []ActivityObjectChange `json:"object_changes"`
+}
+
+type ActivityBalance struct {
+	Owner    sui.Owner `json:"owner"`
+	CoinType string    `json:"coin_type"`
+	Amount   string    `json:"amount"`
+}
+
+type ActivityObjectChange struct {
+	Type            string     `json:"type"`
+	ObjectID        string     `json:"object_id,omitempty"`
+	ObjectType      string     `json:"object_type,omitempty"`
+	Owner           *sui.Owner `json:"owner,omitempty"`
+	Recipient       *sui.Owner `json:"recipient,omitempty"`
+	Version         string     `json:"version,omitempty"`
+	PreviousVersion string     `json:"previous_version,omitempty"`
+	PackageID       string     `json:"package_id,omitempty"`
+	Modules         []string   `json:"modules,omitempty"`
+}
+
+// addAddressStreams follows the transactions sent by each watched address
+// and those sending objects to it. Full nodes have no filter for both, so
+// each direction has its own stream, shared by the subscriptions watching the
+// same address.
+func addAddressStreams(streams transactionStreams, subscription *models.Subscription) {
+	for _, address := range subscription.Addresses {
+		if subscription.AddressDirection != models.AddressDirectionTo {
+			streams.add("address:from:"+address, sui.FromAddress(address), subscription, addressPayloads(models.AddressDirectionFrom, address))
+		}
+
+		if subscription.AddressDirection != models.AddressDirectionFrom {
+			streams.add("address:to:"+address, sui.ToAddress(address), subscription, addressPayloads(models.AddressDirectionTo, address))
+		}
+	}
+}
+
+// addressPayloads describes a transaction once per subscription, from the one
+// stream that delivers it.
+func addressPayloads(direction models.AddressDirection, streamAddress string) streamPayloads {
+	return func(ctx context.Context, block sui.TransactionBlock, subscription *models.Subscription) ([]interface{}, error) {
+		if !deliversTransaction(block, subscription, direction, streamAddress) {
+			return nil, nil
+		}
+		return []interface{}{addressActivity(block, touchedAddresses(block, subscription, streamAddress))}, nil
+	}
+}
+
+// deliversTransaction reports whether a stream is the one delivering a
+// transaction to a subscription. A transaction touching several watched
+// addresses, or sent and received by the same one, is read by several of the
+// subscription's streams: the sender's from stream delivers it when the
+// sender is watched, and otherwise the to stream of the first watched address
+// it sends objects to.
+func deliversTransaction(block sui.TransactionBlock, subscription *models.Subscription, direction models.AddressDirection, streamAddress string) bool {
+	if direction == models.AddressDirectionFrom {
+		return true
+	}
+
+	if subscription.AddressDirection != models.AddressDirectionTo {
+		for _, address := range subscription.Addresses {
+			if sameAddress(block.Sender(), address) {
+				return false
+			}
+		}
+	}
+
+	for _, address := range subscription.Addresses {
+		if address == streamAddress {
+			return true
+		}
+		if receives(block, address) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// touchedAddresses lists, in the subscription's order, the watched addresses a
+// transaction touches in the subscription's direction. The node already
+// matched the stream's address, so it is always included.
+func touchedAddresses(block sui.TransactionBlock, subscription *models.Subscription, streamAddress string) []string {
+	var touched []string
+	for _, address := range subscription.Addresses {
+		if address == streamAddress || touches(block, address, subscription.AddressDirection) {
+			touched = append(touched, address)
+		}
+	}
+	return touched
+}
+
+func touches(block sui.TransactionBlock, address string, direction models.AddressDirection) bool {
+	if direction != models.AddressDirectionTo && sameAddress(block.Sender(), address) {
+		return true
+	}
+
+	if direction == models.AddressDirectionFrom {
+		return false
+	}
+
+	for _, change := range block.BalanceChanges {
+		if ownedBy(&change.Owner, address) {
+			return true
+		}
+	}
+
+	return receives(block, address)
+}
+
+// receives reports whether a transaction left objects owned by an address,
+// which is what the node's ToAddress filter follows.
+func receives(block sui.TransactionBlock, address string) bool {
+	for _, change := range block.ObjectChanges {
+		if ownedBy(change.Owner, address) || ownedBy(change.Recipient, address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ownedBy(owner *sui.Owner, address string) bool {
+	return owner != nil && owner.Kind == sui.OwnerAddress && sameAddress(owner.Address, address)
+}
+
+func sameAddress(a, normalized string) bool {
+	a, err := move.NormalizeAddress(a)
+	return err == nil && a == normalized
+}
+
+func addressActivity(block sui.TransactionBlock, touched []string) *AddressActivity {
+	sender, err := move.NormalizeAddress(block.Sender())
+	if err != nil {
+		sender = block.Sender()
+	}
+
+	activity := &AddressActivity{
+		Digest:         block.Digest,
+		Sender:         sender,
+		Kind:           block.Kind(),
+		Checkpoint:     block.Checkpoint,
+		Addresses:      touched,
+		BalanceChanges: make([]ActivityBalance, 0, len(block.BalanceChanges)),
+		ObjectChanges:  make([]ActivityObjectChange, 0, len(block.ObjectChanges)),
+	}
+
+	if executed := block.Timestamp(); !executed.IsZero() {
+		activity.Timestamp = &executed
+	}
+
+	if block.Effects != nil {
+		activity.Status = block.Effects.Status.Status
+		activity.Error = block.Effects.Status.Error
+		activity.GasUsed = &block.Effects.GasUsed
+	}
+
+	for _, change := range block.BalanceChanges {
+		activity.BalanceChanges = append(activity.BalanceChanges, ActivityBalance{
+			Owner:    change.Owner,
+			CoinType: change.CoinType,
+			Amount:   change.Amount,
+		})
+	}
+
+	for _, change := range block.ObjectChanges {
+		activity.ObjectChanges = append(activity.ObjectChanges, ActivityObjectChange{
+			Type:            change.Type,
+			ObjectID:        change.ObjectID,
+			ObjectType:      change.ObjectType,
+			Owner:           change.Owner,
+			Recipient:       change.Recipient,
+			Version:         change.Version,
+			PreviousVersion: change.PreviousVersion,
+			PackageID:       change.PackageID,
+			Modules:         change.Modules,
+		})
+	}
+
+	return activity
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/sui"
+)
+
+var (
+	alice = "0x" + strings.Repeat("a", 64)
+	bob   = "0x" + strings.Repeat("b", 64)
+	carol = "0x" + strings.Repeat("c", 64)
+)
+
+func transactionBlock(sender string, recipients ...string) sui.TransactionBlock {
+	block := sui.TransactionBlock{Digest: "digest", Transaction: &sui.TransactionInput{}}
+	block.Transaction.Data.Sender = sender
+	for _, recipient := range recipients {
+		block.ObjectChanges = append(block.ObjectChanges, sui.ObjectChange{
+			Type:  sui.ObjectMutated,
+			Owner: &sui.Owner{Kind: sui.OwnerAddress, Address: recipient},
+		})
+	}
+	return block
+}
+
+// TestAddressStreamsDeliverOnce runs a transaction through every stream of a
+// subscription that the node would put it in, and checks that exactly the
+// expected stream delivers it.
+func TestAddressStreamsDeliverOnce(t *testing.T) {
+	tests := []struct {
+		name      string
+		direction models.AddressDirection
+		addresses []string
+		block     sui.TransactionBlock
+		want      string
+	}{
+		{"sent", models.AddressDirectionAny, []string{alice}, transactionBlock(alice, carol), "address:from:" + alice},
+		{"sent to self", models.AddressDirectionAny, []string{alice}, transactionBlock(alice, alice), "address:from:" + alice},
+		{"received", models.AddressDirectionAny, []string{alice}, transactionBlock(carol, alice), "address:to:" + alice},
+		{"sent between watched", models.AddressDirectionAny, []string{alice, bob}, transactionBlock(bob, alice, bob), "address:from:" + bob},
+		{"received by both", models.AddressDirectionAny, []string{alice, bob}, transactionBlock(carol, bob, alice), "address:to:" + alice},
+		{"received by second", models.AddressDirectionAny, []string{alice, bob}, transactionBlock(carol, bob), "address:to:" + bob},
+		{"from only", models.AddressDirectionFrom, []string{alice, bob}, transactionBlock(alice, bob), "address:from:" + alice},
+		{"to only, sent by watched", models.AddressDirectionTo, []string{alice, bob}, transactionBlock(alice, alice, bob), "address:to:" + alice},
+		{"to only, unseen recipient", models.AddressDirectionTo, []string{alice, bob}, transactionBlock(carol, bob), "address:to:" + bob},
+	}
+
+	for _, tt := range tests {
+		subscription := &models.Subscription{Addresses: tt.addresses, AddressDirection: tt.direction}
+
+		var delivered []string
+		for _, address := range tt.addresses {
+			sent := sameAddress(tt.block.Sender(), address)
+			received := receives(tt.block, address)
+
+			if tt.direction != models.AddressDirectionTo && sent && deliversTransaction(tt.block, subscription, models.AddressDirectionFrom, address) {
+				delivered = append(delivered, "address:from:"+address)
+			}
+
+			if tt.direction != models.AddressDirectionFrom && received && deliversTransaction(tt.block, subscription, models.AddressDirectionTo, address) {
+				delivered = append(delivered, "address:to:"+address)
+			}
+		}
+
+		if len(delivered) != 1 || delivered[0] != tt.want {
+			t.Errorf("%s: delivered by %v, want %s", tt.name, delivered, tt.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/open-move/intercord/internal/models"
+)
+
+type AttemptStore interface {
+	Get(ctx context.Context, key string) (*models.AuthThrottle, error)
+	RecordFailure(ctx context.Context, key string, window time.Duration) (*models.AuthThrottle, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+	LogAttempt(ctx context.Context, attempt *models.AuthAttempt) error
+}
+
+type MemoryAttemptStore struct {
+	mu     sync.Mutex
+	states map[string]*models.AuthThrottle
+}
+
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{
+		states: make(map[string]*models.AuthThrottle),
+	}
+}
+
+func (s *MemoryAttemptStore) Get(ctx context.Context, key string) (*models.AuthThrottle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[key]
+	if !ok {
+		return &models.AuthThrottle{Key: key}, nil
+	}
+
+	copied := *state
+	return &copied, nil
+}
+
+func (s *MemoryAttemptStore) RecordFailure(ctx context.Context, key string, window time.Duration) (*models.AuthThrottle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	state, ok := s.states[key]
+	if !ok {
+		state = &models.AuthThrottle{Key: key, WindowStart: now}
+		s.states[key] = state
+	}
+
+	if state.WindowStart.Before(now.Add(-window)) {
+		state.Failures = 0
+		state.WindowStart = now
+	}
+
+	state.Failures++
+	state.LastFailure = now
+
+	s.prune(now, window)
+
+	copied := *state
+	return &copied, nil
+}
+
+func (s *MemoryAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[key]
+	if !ok {
+		state = &models.AuthThrottle{Key: key, WindowStart: time.Now()}
+		s.states[key] = state
+	}
+
+	state.LockedUntil = until
+	return nil
+}
+
+func (s *MemoryAttemptStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.states, key)
+	return nil
+}
+
+func (s *MemoryAttemptStore) LogAttempt(ctx context.Context, attempt *models.AuthAttempt) error {
+	if attempt.Success {
+		return nil
+	}
+
+	log.Printf("Failed %s attempt for %q from %s: %s", attempt.Action, attempt.Email, attempt.IP, attempt.Reason)
+	return nil
+}
+
+func (s *MemoryAttemptStore) prune(now time.Time, window time.Duration) {
+	for key, state := range s.states {
+		if state.LastFailure.Before(now.Add(-window)) && state.LockedUntil.Before(now) {
+			delete(s.states, key)
+		}
+	}
+}
+
+type PostgresAttemptStore struct {
+	db *bun.DB
+}
+
+func NewPostgresAttemptStore(db *bun.DB) *PostgresAttemptStore {
+	return &PostgresAttemptStore{
+		db: db,
+	}
+}
+
+func (s *PostgresAttemptStore) Get(ctx context.Context, key string) (*models.AuthThrottle, error) {
+	state := new(models.AuthThrottle)
+	err := s.db.NewSelect().Model(state).Where("key = ?", key).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &models.AuthThrottle{Key: key}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+func (s *PostgresAttemptStore) RecordFailure(ctx context.Context, key string, window time.Duration) (*models.AuthThrottle, error) {
+	now := time.Now()
+	windowStart := now.Add(-window)
+
+	state := &models.AuthThrottle{
+		Key:         key,
+		Failures:    1,
+		WindowStart: now,
+		LastFailure: now,
+	}
+
+	_, err := s.db.NewInsert().
+		Model(state).
+		On("CONFLICT (key) DO UPDATE").
+		Set("failures = CASE WHEN ath.window_start < ? THEN 1 ELSE ath.failures + 1 END", windowStart).
+		Set("window_start = CASE WHEN ath.window_start < ? THEN EXCLUDED.window_start ELSE ath.window_start END", windowStart).
+		Set("last_failure = EXCLUDED.last_failure").
+		Returning("*").
+		Exec(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+func (s *PostgresAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	now := time.Now()
+	state := &models.AuthThrottle{
+		Key:         key,
+		WindowStart: now,
+		LastFailure: now,
+		LockedUntil: until,
+	}
+
+	_, err := s.db.NewInsert().
+		Model(state).
+		On("CONFLICT (key) DO UPDATE").
+		Set("locked_until = EXCLUDED.locked_until").
+		Exec(ctx)
+
+	return err
+}
+
+func (s *PostgresAttemptStore) Reset(ctx context.Context, key string) error {
+	_, err := s.db.NewDelete().
+		Model((*models.AuthThrottle)(nil)).
+		Where("key = ?", key).
+		Exec(ctx)
+
+	return err
+}
+
+func (s *PostgresAttemptStore) LogAttempt(ctx context.Context, attempt *models.AuthAttempt) error {
+	_, err := s.db.NewInsert().Model(attempt).Exec(ctx)
+	return err
+}
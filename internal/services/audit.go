@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/open-move/intercord/internal/models"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+type AuditService struct {
+	db *bun.DB
+}
+
+func NewAuditService(db *bun.DB) *AuditService {
+	return &AuditService{
+		db: db,
+	}
+}
+
+type AuditEntry struct {
+	TeamID       *int64
+	ActorID      int64
+	Action       models.AuditAction
+	ResourceType string
+	ResourceID   int64
+	Changes      map[string]models.AuditChange
+}
+
+type AuditFilter struct {
+	Action       string
+	ActorID      int64
+	ResourceType string
+	ResourceID   int64
+	Since        *time.Time
+	Until        *time.Time
+	Cursor       int64
+	Limit        int
+}
+
+// Record appends an event to the team's audit log. Personal resources have no
+// team and are not audited. Pass the transaction that made the change when
+// there is one so the event is only kept if the change is.
+func (s *AuditService) Record(ctx context.Context, db bun.IDB, entry AuditEntry) error {
+	if entry.TeamID == nil {
+		return nil
+	}
+
+	event := &models.TeamAuditEvent{
+		TeamID:       *entry.TeamID,
+		ActorID:      entry.ActorID,
+		Action:       entry.Action,
+		ResourceType: entry.ResourceType,
+		ResourceID:   entry.ResourceID,
+		Changes:      entry.Changes,
+	}
+
+	_, err := db.NewInsert().Model(event).Exec(ctx)
+	return err
+}
+
+func (s *AuditService) List(ctx context.Context, teamID int64, filter AuditFilter) ([]models.TeamAuditEvent, int64, error) {
+	limit := filter.Limit
+	if limit < 1 {
+		limit = defaultAuditPageSize
+	}
+	if limit > maxAuditPageSize {
+		limit = maxAuditPageSize
+	}
+
+	query := s.db.NewSelect().
+		Model((*models.TeamAuditEvent)(nil)).
+		Relation("Actor").
+		Where("tae.team_id = ?", teamID)
+
+	if filter.Action != "" {
+		query = query.Where("tae.action = ?", filter.Action)
+	}
+	if filter.ActorID != 0 {
+		query = query.Where("tae.actor_id = ?", filter.ActorID)
+	}
+	if filter.ResourceType != "" {
+		query = query.Where("tae.resource_type = ?", filter.ResourceType)
+	}
+	if filter.ResourceID != 0 {
+		query = query.Where("tae.resource_id = ?", filter.ResourceID)
+	}
+	if filter.Since != nil {
+		query = query.Where("tae.created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("tae.created_at < ?", *filter.Until)
+	}
+	if filter.Cursor != 0 {
+		query = query.Where("tae.id < ?", filter.Cursor)
+	}
+
+	var events []models.TeamAuditEvent
+	err := query.
+		Order("tae.id DESC").
+		Limit(limit+1).
+		Scan(ctx, &events)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var nextCursor int64
+	if len(events) > limit {
+		events = events[:limit]
+		nextCursor = events[limit-1].ID
+	}
+
+	return events, nextCursor, nil
+}
+
+func auditDiff(before, after map[string]interface{}) map[string]models.AuditChange {
+	changes := map[string]models.AuditChange{}
+	for key, value := range after {
+		if !reflect.DeepEqual(before[key], value) {
+			changes[key] = models.AuditChange{From: before[key], To: value}
+		}
+	}
+	for key, value := range before {
+		if _, ok := after[key]; !ok {
+			changes[key] = models.AuditChange{From: value}
+		}
+	}
+	return changes
+}
+
+func subscriptionAuditFields(subscription *models.Subscription) map[string]interface{} {
+	return map[string]interface{}{
+		"name":                subscription.Name,
+		"description":         subscription.Description,
+		"event_type":          subscription.EventType,
+		"filter":              subscription.Filter,
+		"senders":             subscription.Senders,
+		"emitter_package":     subscription.EmitterPackage,
+		"emitter_module":      subscription.EmitterModule,
+		"transaction_kinds":   subscription.TransactionKinds,
+		"addresses":           subscription.Addresses,
+		"address_direction":   subscription.AddressDirection,
+		"object_ids":          subscription.ObjectIDs,
+		"object_type":         subscription.ObjectType,
+		"object_change_types": subscription.ObjectChangeTypes,
+		"balance_address":     subscription.BalanceAddress,
+		"coin_type":           subscription.CoinType,
+		"threshold":           subscription.Threshold,
+		"threshold_direction": subscription.ThresholdDirection,
+		"hysteresis":          subscription.Hysteresis,
+		"package_id":          subscription.PackageID,
+		"system_events":       subscription.SystemEvents,
+		"validators":          subscription.Validators,
+		"is_active":           subscription.IsActive,
+	}
+}
+
+func roleAuditFields(role *models.TeamRoleDefinition) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        role.Name,
+		"description": role.Description,
+		"permissions": role.Permissions,
+	}
+}
+
+func channelAuditFields(channel *models.Channel) map[string]interface{} {
+	fields := map[string]interface{}{
+		"name":        channel.Name,
+		"description": channel.Description,
+		"type":        string(channel.Type),
+	}
+
+	var config ChannelConfig
+	if err := json.Unmarshal([]byte(channel.Config), &config); err != nil {
+		return fields
+	}
+
+	values := map[string]string{
+		"config.webhook_url":      fingerprintURL(config.WebhookURL),
+		"config.discord_webhook":  fingerprintURL(config.DiscordWebhook),
+		"config.email_address":    config.EmailAddress,
+		"config.telegram_chat_id": config.TelegramChatID,
+	}
+	for key, value := range values {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+
+	return fields
+}
+
+// fingerprintURL hides the secret part of a webhook URL but keeps a short hash
+// of it, so a changed secret still shows up as a change in the audit log.
+func fingerprintURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(raw))
+	return redactURL(raw) + " (sha256:" + hex.EncodeToString(sum[:4]) + ")"
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/move"
+	"github.com/open-move/intercord/internal/sui"
+)
+
+// Balance alert transitions.
+const (
+	BalanceAlertTriggered = "triggered"
+	BalanceAlertRecovered = "recovered"
+)
+
+// BalanceAlert is the notification payload of a balance subscription: the
+// watched balance went past its threshold, or back far enough to re-arm.
+type BalanceAlert struct {
+	Alert      string     `json:"alert"`
+	Address    string     `json:"address"`
+	CoinType   string     `json:"coin_type"`
+	Balance    string     `json:"balance"`
+	RawBalance string     `json:"raw_balance"`
+	Change     string     `json:"change"`
+	Threshold  string     `json:"threshold"`
+	Direction  string     `json:"direction"`
+	Hysteresis string     `json:"hysteresis"`
+	Digest     string     `json:"digest,omitempty"`
+	Timestamp  *time.Time `json:"timestamp,omitempty"`
+}
+
+// balanceLimits returns a subscription's threshold and hysteresis in the
+// coin's smallest unit.
+func balanceLimits(subscription *models.Subscription) (threshold, hysteresis *big.Int, err error) {
+	threshold, err = parseAmount(subscription.Threshold, subscription.CoinDecimals)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid threshold: %w", err)
+	}
+
+	hysteresis, err = parseAmount(subscription.Hysteresis, subscription.CoinDecimals)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid hysteresis: %w", err)
+	}
+
+	return threshold, hysteresis, nil
+}
+
+// updateBalance records a new balance and returns the alert it causes, if
+// any. Once triggered, an alert only re-arms when the balance moves back past
+// the threshold by more than the hysteresis, so a balance hovering around the
+// threshold doesn't alert on every transaction.
+func updateBalance(subscription *models.Subscription, balance *big.Int) (string, error) {
+	threshold, hysteresis, err := balanceLimits(subscription)
+	if err != nil {
+		return "", err
+	}
+
+	subscription.Balance = balance.String()
+
+	var past, recovered bool
+	if subscription.ThresholdDirection == models.ThresholdAbove {
+		past = balance.Cmp(threshold) > 0
+		recovered = balance.Cmp(new(big.Int).Sub(threshold, hysteresis)) <= 0
+	} else {
+		past = balance.Cmp(threshold) < 0
+		recovered = balance.Cmp(new(big.Int).Add(threshold, hysteresis)) >= 0
+	}
+
+	switch {
+	case !subscription.Triggered && past:
+		subscription.Triggered = true
+		return BalanceAlertTriggered, nil
+	case subscription.Triggered && recovered:
+		subscription.Triggered = false
+		return BalanceAlertRecovered, nil
+	}
+
+	return "", nil
+}
+
+// addBalanceStreams follows the transactions sent by and sending objects to a
+// balance subscription's address, on separate streams since the node has no
+// filter for both. Subscriptions on the same address share the streams,
+// whatever their coin.
+func addBalanceStreams(streams transactionStreams, subscription *models.Subscription) {
+	address := subscription.BalanceAddress
+	sent := streams.add("balance:from:"+address, sui.FromAddress(address), subscription, balancePayloads)
+	sent.save = saveBalances
+
+	received := streams.add("balance:to:"+address, sui.ToAddress(address), subscription, receivedBalancePayloads)
+	received.save = saveBalances
+}
+
+// receivedBalancePayloads applies the transactions of the to stream that the
+// address didn't send. Those it sent are in the from stream too, and applying
+// them from both would count their balance changes twice.
+func receivedBalancePayloads(ctx context.Context, block sui.TransactionBlock, subscription *models.Subscription) ([]interface{}, error) {
+	if sameAddress(block.Sender(), subscription.BalanceAddress) {
+		return nil, nil
+	}
+	return balancePayloads(ctx, block, subscription)
+}
+
+// balancePayloads applies a transaction's balance changes to a subscription's
+// balance.
+func balancePayloads(ctx context.Context, block sui.TransactionBlock, subscription *models.Subscription) ([]interface{}, error) {
+	change := new(big.Int)
+	changed := false
+
+	for _, balanceChange := range block.BalanceChanges {
+		if !ownedBy(&balanceChange.Owner, subscription.BalanceAddress) || !sameStructType(balanceChange.CoinType, subscription.CoinType) {
+			continue
+		}
+
+		amount, ok := new(big.Int).SetString(balanceChange.Amount, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid balance change %q in %s", balanceChange.Amount, block.Digest)
+		}
+		change.Add(change, amount)
+		changed = true
+	}
+
+	if !changed {
+		return nil, nil
+	}
+
+	balance, ok := new(big.Int).SetString(subscription.Balance, 10)
+	if !ok {
+		balance = new(big.Int)
+	}
+	balance.Add(balance, change)
+
+	alert, err := updateBalance(subscription, balance)
+	if err != nil || alert == "" {
+		return nil, err
+	}
+
+	payload := balanceAlert(subscription, alert, balance, change)
+	payload.Digest = block.Digest
+	if executed := block.Timestamp(); !executed.IsZero() {
+		payload.Timestamp = &executed
+	}
+
+	return []interface{}{payload}, nil
+}
+
+func balanceAlert(subscription *models.Subscription, alert string, balance, change *big.Int) *BalanceAlert {
+	return &BalanceAlert{
+		Alert:      alert,
+		Address:    subscription.BalanceAddress,
+		CoinType:   subscription.CoinType,
+		Balance:    formatAmount(balance, subscription.CoinDecimals),
+		RawBalance: balance.String(),
+		Change:     formatAmount(change, subscription.CoinDecimals),
+		Threshold:  subscription.Threshold,
+		Direction:  string(subscription.ThresholdDirection),
+		Hysteresis: subscription.Hysteresis,
+	}
+}
+
+// saveBalances stores the balances and alert states reached by a page. A
+// subscription whose address or coin was changed meanwhile was re-read from
+// chain, so it is left alone.
+func saveBalances(ctx context.Context, tx bun.Tx, subscriptions []*models.Subscription) error {
+	for _, subscription := range subscriptions {
+		_, err := tx.NewUpdate().
+			Model(subscription).
+			Column("balance", "triggered").
+			WherePK().
+			Where("balance_address = ?", subscription.BalanceAddress).
+			Where("coin_type = ?", subscription.CoinType).
+			Exec(ctx)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reconcileBalances re-reads the balances of balance subscriptions from the
+// node every BalanceReconcileInterval. Following transactions drifts if one is
+// missed or counted twice, as around the read made when a subscription is
+// saved, and the node's balance sets it right.
+func (s *IngestionService) reconcileBalances(ctx context.Context, subscriptions []models.Subscription, channels map[int64][]int64) {
+	interval := s.config.BalanceReconcileInterval
+
+	s.mu.Lock()
+	due := interval > 0 && time.Since(s.balancesReconciledAt) >= interval
+	if due {
+		s.balancesReconciledAt = time.Now()
+	}
+	s.mu.Unlock()
+
+	if !due {
+		return
+	}
+
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		if subscription.Kind != models.SubscriptionKindBalance {
+			continue
+		}
+
+		if err := s.reconcileBalance(ctx, subscription, channels[subscription.ID]); err != nil {
+			log.Printf("Reconciling the balance of subscription %d failed: %v", subscription.ID, err)
+		}
+	}
+}
+
+// reconcileBalance applies the node's balance to a subscription, alerting as
+// if a transaction had moved it there.
+func (s *IngestionService) reconcileBalance(ctx context.Context, subscription *models.Subscription, channels []int64) (err error) {
+	reported, err := s.client.GetBalance(ctx, subscription.BalanceAddress, subscription.CoinType)
+	if err != nil {
+		return err
+	}
+
+	balance, ok := new(big.Int).SetString(reported.TotalBalance, 10)
+	if !ok {
+		return fmt.Errorf("invalid balance %q", reported.TotalBalance)
+	}
+
+	if balance.String() == subscription.Balance {
+		return nil
+	}
+
+	saved := *subscription
+	defer func() {
+		if err != nil {
+			*subscription = saved
+		}
+	}()
+
+	previous, ok := new(big.Int).SetString(subscription.Balance, 10)
+	if !ok {
+		previous = new(big.Int)
+	}
+
+	alert, err := updateBalance(subscription, balance)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	if alert != "" {
+		reconciled := balanceAlert(subscription, alert, balance, new(big.Int).Sub(balance, previous))
+		now := time.Now()
+		reconciled.Timestamp = &now
+
+		if payload, err = json.Marshal(reconciled); err != nil {
+			return err
+		}
+	}
+
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := saveBalances(ctx, tx, []*models.Subscription{subscription}); err != nil {
+			return err
+		}
+
+		if payload == nil {
+			return nil
+		}
+
+		return s.enqueue(ctx, tx, func(queue *notificationQueue) error {
+			return queue.add(subscription, channels, payload)
+		})
+	})
+}
+
+// sameStructType compares a type as the node spells it with a canonical one.
+func sameStructType(typ, canonical string) bool {
+	tag, err := move.ParseStructTag(typ)
+	return err == nil && tag.String() == canonical
+}
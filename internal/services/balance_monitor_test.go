@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/sui"
+)
+
+func TestUpdateBalance(t *testing.T) {
+	tests := []struct {
+		name      string
+		direction models.ThresholdDirection
+		triggered bool
+		balances  []int64
+		alerts    []string
+	}{
+		{
+			name:      "below, triggers once",
+			direction: models.ThresholdBelow,
+			balances:  []int64{150, 99, 50, 10},
+			alerts:    []string{"", BalanceAlertTriggered, "", ""},
+		},
+		{
+			name:      "below, hysteresis holds off recovery",
+			direction: models.ThresholdBelow,
+			balances:  []int64{99, 105, 110, 99, 111, 99},
+			alerts:    []string{BalanceAlertTriggered, "", BalanceAlertRecovered, BalanceAlertTriggered, BalanceAlertRecovered, BalanceAlertTriggered},
+		},
+		{
+			name:      "below, threshold itself is not past",
+			direction: models.ThresholdBelow,
+			balances:  []int64{100},
+			alerts:    []string{""},
+		},
+		{
+			name:      "below, starts triggered",
+			direction: models.ThresholdBelow,
+			triggered: true,
+			balances:  []int64{50, 120},
+			alerts:    []string{"", BalanceAlertRecovered},
+		},
+		{
+			name:      "above",
+			direction: models.ThresholdAbove,
+			balances:  []int64{100, 101, 95, 90, 101},
+			alerts:    []string{"", BalanceAlertTriggered, "", BalanceAlertRecovered, BalanceAlertTriggered},
+		},
+	}
+
+	for _, tt := range tests {
+		subscription := &models.Subscription{
+			Threshold:          "100",
+			Hysteresis:         "10",
+			ThresholdDirection: tt.direction,
+			Triggered:          tt.triggered,
+		}
+
+		for i, balance := range tt.balances {
+			alert, err := updateBalance(subscription, big.NewInt(balance))
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.name, err)
+			}
+
+			if alert != tt.alerts[i] {
+				t.Errorf("%s: balance %d alerted %q, want %q", tt.name, balance, alert, tt.alerts[i])
+			}
+
+			if subscription.Balance != big.NewInt(balance).String() {
+				t.Errorf("%s: recorded balance %s, want %d", tt.name, subscription.Balance, balance)
+			}
+		}
+	}
+}
+
+func TestUpdateBalanceDecimals(t *testing.T) {
+	subscription := &models.Subscription{
+		Threshold:          "1.5",
+		Hysteresis:         "0.25",
+		CoinDecimals:       9,
+		ThresholdDirection: models.ThresholdBelow,
+	}
+
+	for _, step := range []struct {
+		balance int64
+		alert   string
+	}{
+		{1_500_000_000, ""},
+		{1_499_999_999, BalanceAlertTriggered},
+		{1_749_999_999, ""},
+		{1_750_000_000, BalanceAlertRecovered},
+	} {
+		alert, err := updateBalance(subscription, big.NewInt(step.balance))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if alert != step.alert {
+			t.Errorf("balance %d alerted %q, want %q", step.balance, alert, step.alert)
+		}
+	}
+
+	subscription.Threshold = "0.0000000001"
+	if _, err := updateBalance(subscription, big.NewInt(1)); err == nil {
+		t.Error("threshold finer than the coin's decimals: got no error")
+	}
+}
+
+// TestBalanceStreamsCountOnce checks that a transaction the address both sent
+// and received coins in, which is in both of its streams, only moves the
+// balance once.
+func TestBalanceStreamsCountOnce(t *testing.T) {
+	coinType := "0x0000000000000000000000000000000000000000000000000000000000000002::sui::SUI"
+	subscription := &models.Subscription{
+		BalanceAddress:     alice,
+		CoinType:           coinType,
+		Balance:            "1000",
+		Threshold:          "0",
+		Hysteresis:         "0",
+		ThresholdDirection: models.ThresholdBelow,
+	}
+
+	change := func(owner, amount string) sui.BalanceChange {
+		return sui.BalanceChange{Owner: sui.Owner{Kind: sui.OwnerAddress, Address: owner}, CoinType: "0x2::sui::SUI", Amount: amount}
+	}
+
+	sent := transactionBlock(alice, alice)
+	sent.BalanceChanges = []sui.BalanceChange{change(alice, "-300"), change(bob, "290")}
+
+	received := transactionBlock(bob, alice)
+	received.BalanceChanges = []sui.BalanceChange{change(alice, "50"), change(bob, "-60")}
+
+	ctx := context.Background()
+	for _, step := range []struct {
+		payloads streamPayloads
+		block    sui.TransactionBlock
+	}{
+		{balancePayloads, sent},
+		{receivedBalancePayloads, sent},
+		{receivedBalancePayloads, received},
+	} {
+		if _, err := step.payloads(ctx, step.block, subscription); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if subscription.Balance != "750" {
+		t.Errorf("balance %s, want 750", subscription.Balance)
+	}
+}
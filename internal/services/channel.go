@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"net/url"
 
 	"github.com/uptrace/bun"
 
@@ -11,14 +12,16 @@ import (
 )
 
 type ChannelService struct {
-	db          *bun.DB
-	teamService *TeamService
+	db           *bun.DB
+	auditService *AuditService
+	quotaService *QuotaService
 }
 
-func NewChannelService(db *bun.DB, teamService *TeamService) *ChannelService {
+func NewChannelService(db *bun.DB, auditService *AuditService, quotaService *QuotaService) *ChannelService {
 	return &ChannelService{
-		db:          db,
-		teamService: teamService,
+		db:           db,
+		auditService: auditService,
+		quotaService: quotaService,
 	}
 }
 
@@ -29,6 +32,39 @@ type ChannelConfig struct {
 	DiscordWebhook string `json:"discord_webhook,omitempty"`
 }
 
+func (c ChannelConfig) Redacted() ChannelConfig {
+	c.WebhookURL = redactURL(c.WebhookURL)
+	c.DiscordWebhook = redactURL(c.DiscordWebhook)
+	return c
+}
+
+func redactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return "[redacted]"
+	}
+
+	return parsed.Scheme + "://" + parsed.Host + "/[redacted]"
+}
+
+func RedactChannelConfig(config string) string {
+	var parsed ChannelConfig
+	if err := json.Unmarshal([]byte(config), &parsed); err != nil {
+		return "{}"
+	}
+
+	redacted, err := json.Marshal(parsed.Redacted())
+	if err != nil {
+		return "{}"
+	}
+
+	return string(redacted)
+}
+
 type CreateChannelInput struct {
 	Name        string        `json:"name" binding:"required"`
 	Description string        `json:"description"`
@@ -49,18 +85,6 @@ type SubscribeChannelInput struct {
 }
 
 func (s *ChannelService) Create(ctx context.Context, input CreateChannelInput, userID int64) (*models.Channel, error) {
-
-	if input.TeamID != nil && *input.TeamID != 0 {
-		membership, err := s.teamService.GetMembership(ctx, *input.TeamID, userID)
-		if err != nil {
-			return nil, errors.New("you are not a member of this team")
-		}
-
-		if membership.Role != models.TeamRoleOwner && membership.Role != models.TeamRoleAdmin {
-			return nil, errors.New("you don't have permission to create channels for this team")
-		}
-	}
-
 	switch models.ChannelType(input.Type) {
 	case models.ChannelTypeWebhook:
 		if input.Config.WebhookURL == "" {
@@ -96,7 +120,28 @@ func (s *ChannelService) Create(ctx context.Context, input CreateChannelInput, u
 		UserID:      userID,
 	}
 
-	_, err = s.db.NewInsert().Model(channel).Exec(ctx)
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if channel.TeamID != nil {
+			if err := s.quotaService.CheckLimit(ctx, tx, *channel.TeamID, QuotaChannels, 1); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.NewInsert().Model(channel).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return s.auditService.Record(ctx, tx, AuditEntry{
+			TeamID:       channel.TeamID,
+			ActorID:      userID,
+			Action:       models.AuditChannelCreated,
+			ResourceType: "channel",
+			ResourceID:   channel.ID,
+			Changes:      auditDiff(nil, channelAuditFields(channel)),
+		})
+	})
+
 	if err != nil {
 		return nil, err
 	}
@@ -113,6 +158,15 @@ func (s *ChannelService) GetByID(ctx context.Context, id int64) (*models.Channel
 	return channel, nil
 }
 
+func (s *ChannelService) GetTrashedByID(ctx context.Context, id int64) (*models.Channel, error) {
+	channel := new(models.Channel)
+	err := s.db.NewSelect().Model(channel).Where("id = ?", id).WhereDeleted().Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return channel, nil
+}
+
 func (s *ChannelService) GetUserChannels(ctx context.Context, userID int64) ([]models.Channel, error) {
 	var channels []models.Channel
 	err := s.db.NewSelect().
@@ -148,20 +202,7 @@ func (s *ChannelService) Update(ctx context.Context, id int64, input UpdateChann
 		return nil, errors.New("channel not found")
 	}
 
-	if channel.UserID != userID {
-		if channel.TeamID == nil {
-			return nil, errors.New("you don't have permission to update this channel")
-		}
-
-		membership, err := s.teamService.GetMembership(ctx, *channel.TeamID, userID)
-		if err != nil {
-			return nil, errors.New("you don't have permission to update this channel")
-		}
-
-		if membership.Role != models.TeamRoleOwner && membership.Role != models.TeamRoleAdmin {
-			return nil, errors.New("you don't have permission to update this channel")
-		}
-	}
+	before := channelAuditFields(channel)
 
 	if input.Name != "" {
 		channel.Name = input.Name
@@ -200,10 +241,30 @@ func (s *ChannelService) Update(ctx context.Context, id int64, input UpdateChann
 		channel.Config = string(configJSON)
 	}
 
-	_, err = s.db.NewUpdate().Model(channel).
-		Column("name", "description", "config", "updated_at").
-		Where("id = ?", id).
-		Exec(ctx)
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().Model(channel).
+			Column("name", "description", "config", "updated_at").
+			Where("id = ?", id).
+			Exec(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		changes := auditDiff(before, channelAuditFields(channel))
+		if len(changes) == 0 {
+			return nil
+		}
+
+		return s.auditService.Record(ctx, tx, AuditEntry{
+			TeamID:       channel.TeamID,
+			ActorID:      userID,
+			Action:       models.AuditChannelUpdated,
+			ResourceType: "channel",
+			ResourceID:   channel.ID,
+			Changes:      changes,
+		})
+	})
 
 	if err != nil {
 		return nil, err
@@ -219,86 +280,40 @@ func (s *ChannelService) Delete(ctx context.Context, id int64, userID int64) err
 		return errors.New("channel not found")
 	}
 
-	if channel.UserID != userID {
-		if channel.TeamID == nil {
-			return errors.New("you don't have permission to delete this channel")
-		}
-
-		membership, err := s.teamService.GetMembership(ctx, *channel.TeamID, userID)
-		if err != nil {
-			return errors.New("you don't have permission to delete this channel")
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		deletedAt := trashTime()
+		if err := moveToTrash(ctx, tx, (*models.SubscriptionChannel)(nil), deletedAt, "channel_id = ?", id); err != nil {
+			return err
 		}
 
-		if membership.Role != models.TeamRoleOwner && membership.Role != models.TeamRoleAdmin {
-			return errors.New("you don't have permission to delete this channel")
+		if err := moveToTrash(ctx, tx, (*models.Channel)(nil), deletedAt, "id = ?", id); err != nil {
+			return err
 		}
-	}
-
-	_, err = s.db.NewDelete().
-		Model((*models.SubscriptionChannel)(nil)).
-		Where("channel_id = ?", id).
-		Exec(ctx)
-
-	if err != nil {
-		return err
-	}
-
-	_, err = s.db.NewDelete().
-		Model(channel).
-		Where("id = ?", id).
-		Exec(ctx)
 
-	if err != nil {
-		return err
-	}
-
-	return nil
+		return s.auditService.Record(ctx, tx, AuditEntry{
+			TeamID:       channel.TeamID,
+			ActorID:      userID,
+			Action:       models.AuditChannelDeleted,
+			ResourceType: "channel",
+			ResourceID:   channel.ID,
+			Changes:      auditDiff(channelAuditFields(channel), nil),
+		})
+	})
 }
 
 func (s *ChannelService) SubscribeToSubscription(ctx context.Context, input SubscribeChannelInput, userID int64) error {
-
 	subscription := new(models.Subscription)
 	err := s.db.NewSelect().Model(subscription).Where("id = ?", input.SubscriptionID).Scan(ctx)
 	if err != nil {
 		return errors.New("subscription not found")
 	}
 
-	if subscription.UserID != userID {
-		if subscription.TeamID == nil {
-			return errors.New("you don't have permission to modify this subscription")
-		}
-
-		membership, err := s.teamService.GetMembership(ctx, *subscription.TeamID, userID)
-		if err != nil {
-			return errors.New("you don't have permission to modify this subscription")
-		}
-
-		if membership.Role != models.TeamRoleOwner && membership.Role != models.TeamRoleAdmin {
-			return errors.New("you don't have permission to modify this subscription")
-		}
-	}
-
 	channel := new(models.Channel)
 	err = s.db.NewSelect().Model(channel).Where("id = ?", input.ChannelID).Scan(ctx)
 	if err != nil {
 		return errors.New("channel not found")
 	}
 
-	if channel.UserID != userID {
-		if channel.TeamID == nil {
-			return errors.New("you don't have permission to use this channel")
-		}
-
-		membership, err := s.teamService.GetMembership(ctx, *channel.TeamID, userID)
-		if err != nil {
-			return errors.New("you don't have permission to use this channel")
-		}
-
-		if membership.Role != models.TeamRoleOwner && membership.Role != models.TeamRoleAdmin {
-			return errors.New("you don't have permission to use this channel")
-		}
-	}
-
 	if subscription.TeamID != nil && channel.TeamID != nil {
 		if *subscription.TeamID != *channel.TeamID {
 			return errors.New("subscription and channel must belong to the same team")
@@ -321,46 +336,52 @@ func (s *ChannelService) SubscribeToSubscription(ctx context.Context, input Subs
 		ChannelID:      input.ChannelID,
 	}
 
-	_, err = s.db.NewInsert().Model(subscriptionChannel).Exec(ctx)
-	if err != nil {
-		return err
-	}
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().Model(subscriptionChannel).Exec(ctx)
+		if err != nil {
+			return err
+		}
 
-	return nil
+		return s.auditService.Record(ctx, tx, AuditEntry{
+			TeamID:       subscription.TeamID,
+			ActorID:      userID,
+			Action:       models.AuditSubscriptionChannelLinked,
+			ResourceType: "subscription",
+			ResourceID:   subscription.ID,
+			Changes:      map[string]models.AuditChange{"channel_id": {To: channel.ID}},
+		})
+	})
 }
 
 func (s *ChannelService) UnsubscribeFromSubscription(ctx context.Context, input SubscribeChannelInput, userID int64) error {
-
 	subscription := new(models.Subscription)
 	err := s.db.NewSelect().Model(subscription).Where("id = ?", input.SubscriptionID).Scan(ctx)
 	if err != nil {
 		return errors.New("subscription not found")
 	}
 
-	if subscription.UserID != userID {
-		if subscription.TeamID == nil {
-			return errors.New("you don't have permission to modify this subscription")
-		}
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewDelete().
+			Model((*models.SubscriptionChannel)(nil)).
+			Where("subscription_id = ?", input.SubscriptionID).
+			Where("channel_id = ?", input.ChannelID).
+			Exec(ctx)
 
-		membership, err := s.teamService.GetMembership(ctx, *subscription.TeamID, userID)
 		if err != nil {
-			return errors.New("you don't have permission to modify this subscription")
+			return errors.New("failed to unsubscribe channel")
 		}
 
-		if membership.Role != models.TeamRoleOwner && membership.Role != models.TeamRoleAdmin {
-			return errors.New("you don't have permission to modify this subscription")
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			return errors.New("channel is not subscribed to this subscription")
 		}
-	}
-
-	_, err = s.db.NewDelete().
-		Model((*models.SubscriptionChannel)(nil)).
-		Where("subscription_id = ?", input.SubscriptionID).
-		Where("channel_id = ?", input.ChannelID).
-		Exec(ctx)
-
-	if err != nil {
-		return errors.New("failed to unsubscribe channel")
-	}
 
-	return nil
+		return s.auditService.Record(ctx, tx, AuditEntry{
+			TeamID:       subscription.TeamID,
+			ActorID:      userID,
+			Action:       models.AuditSubscriptionChannelUnlinked,
+			ResourceType: "subscription",
+			ResourceID:   subscription.ID,
+			Changes:      map[string]models.AuditChange{"channel_id": {From: input.ChannelID}},
+		})
+	})
 }
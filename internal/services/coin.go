@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/open-move/intercord/internal/sui"
+)
+
+// CoinService looks up coin metadata and balances on chain.
+type CoinService struct {
+	client *sui.Client
+
+	mu       sync.Mutex
+	metadata map[string]*sui.CoinMetadata
+}
+
+func NewCoinService(client *sui.Client) *CoinService {
+	return &CoinService{
+		client:   client,
+		metadata: make(map[string]*sui.CoinMetadata),
+	}
+}
+
+// Metadata returns a coin type's metadata. It never changes once published,
+// so it is cached for good.
+func (s *CoinService) Metadata(ctx context.Context, coinType string) (*sui.CoinMetadata, error) {
+	s.mu.Lock()
+	metadata, ok := s.metadata[coinType]
+	s.mu.Unlock()
+
+	if ok {
+		return metadata, nil
+	}
+
+	metadata, err := s.client.GetCoinMetadata(ctx, coinType)
+	if err != nil {
+		return nil, err
+	}
+	if metadata == nil {
+		return nil, fmt.Errorf("coin type %s has no metadata", coinType)
+	}
+
+	s.mu.Lock()
+	s.metadata[coinType] = metadata
+	s.mu.Unlock()
+
+	return metadata, nil
+}
+
+// Balance returns an address's balance of a coin type in the coin's smallest
+// unit.
+func (s *CoinService) Balance(ctx context.Context, owner, coinType string) (*big.Int, error) {
+	balance, err := s.client.GetBalance(ctx, owner, coinType)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, ok := new(big.Int).SetString(balance.TotalBalance, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid balance %q", balance.TotalBalance)
+	}
+	return raw, nil
+}
+
+// decimalAmount is a plain decimal number. big.Rat also reads exponents,
+// fractions, digit separators and hex, which are not accepted as amounts.
+var decimalAmount = regexp.MustCompile(`^-?(\d+\.?\d*|\.\d+)$`)
+
+// parseAmount converts a decimal amount such as "500" or "0.25" into the
+// coin's smallest unit.
+func parseAmount(amount string, decimals int) (*big.Int, error) {
+	amount = strings.TrimSpace(amount)
+	if !decimalAmount.MatchString(amount) {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+
+	value, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+	if value.Sign() < 0 {
+		return nil, errors.New("amounts can't be negative")
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	value.Mul(value, new(big.Rat).SetInt(scale))
+	if !value.IsInt() {
+		return nil, fmt.Errorf("amount %q has more than %d decimals", amount, decimals)
+	}
+
+	return new(big.Int).Set(value.Num()), nil
+}
+
+// formatAmount converts an amount in the coin's smallest unit into a decimal
+// string.
+func formatAmount(raw *big.Int, decimals int) string {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	whole, fraction := new(big.Int).QuoRem(new(big.Int).Abs(raw), scale, new(big.Int))
+
+	formatted := whole.String()
+	if fraction.Sign() != 0 {
+		digits := fmt.Sprintf("%0*s", decimals, fraction.String())
+		formatted += "." + strings.TrimRight(digits, "0")
+	}
+
+	if raw.Sign() < 0 {
+		return "-" + formatted
+	}
+	return formatted
+}
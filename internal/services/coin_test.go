@@ -0,0 +1,90 @@
+package services
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		amount   string
+		decimals int
+		want     string
+		err      bool
+	}{
+		{"500", 9, "500000000000", false},
+		{"0.25", 9, "250000000", false},
+		{" 1.5 ", 6, "1500000", false},
+		{".5", 1, "5", false},
+		{"2.", 0, "2", false},
+		{"0", 9, "0", false},
+		{"0.000000001", 9, "1", false},
+		{"123456789012345678901234567890", 0, "123456789012345678901234567890", false},
+		{"0.0000000001", 9, "", true},
+		{"1.5", 0, "", true},
+		{"-1", 9, "", true},
+		{"", 9, "", true},
+		{".", 9, "", true},
+		{"1e9", 0, "", true},
+		{"1/2", 0, "", true},
+		{"0x10", 0, "", true},
+		{"1_000", 0, "", true},
+		{"1p2", 0, "", true},
+		{"+1", 0, "", true},
+		{"one", 0, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseAmount(tt.amount, tt.decimals)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%q with %d decimals: got %s, want an error", tt.amount, tt.decimals, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%q with %d decimals: unexpected error: %v", tt.amount, tt.decimals, err)
+			continue
+		}
+
+		if got.String() != tt.want {
+			t.Errorf("%q with %d decimals: got %s, want %s", tt.amount, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		raw      string
+		decimals int
+		want     string
+	}{
+		{"500000000000", 9, "500"},
+		{"250000000", 9, "0.25"},
+		{"1", 9, "0.000000001"},
+		{"1500000", 6, "1.5"},
+		{"0", 9, "0"},
+		{"42", 0, "42"},
+		{"-1500000", 6, "-1.5"},
+		{"-1", 9, "-0.000000001"},
+		{"123456789012345678901234567890", 18, "123456789012.34567890123456789"},
+	}
+
+	for _, tt := range tests {
+		raw, _ := new(big.Int).SetString(tt.raw, 10)
+		if got := formatAmount(raw, tt.decimals); got != tt.want {
+			t.Errorf("%s with %d decimals: got %s, want %s", tt.raw, tt.decimals, got, tt.want)
+		}
+
+		if raw.Sign() < 0 {
+			continue
+		}
+
+		// Formatting and parsing back gives the same amount.
+		parsed, err := parseAmount(formatAmount(raw, tt.decimals), tt.decimals)
+		if err != nil || parsed.Cmp(raw) != 0 {
+			t.Errorf("%s with %d decimals: parsed back as %v, %v", tt.raw, tt.decimals, parsed, err)
+		}
+	}
+}
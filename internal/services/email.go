@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"html/template"
 	"net/smtp"
+	"time"
 
 	"github.com/open-move/intercord/internal/config"
 )
@@ -101,4 +102,78 @@ func (s *EmailService) SendTeamInviteEmail(to, inviterName, teamName, inviteLink
 	`, inviterName, teamName, inviteLink)
 
 	return s.SendEmail(to, subject, body)
-}
\ No newline at end of file
+}
+func (s *EmailService) SendAccountLockedEmail(to string, lockedUntil time.Time) error {
+	subject := "Your account has been temporarily locked"
+	body := fmt.Sprintf(`
+	<h1>Account temporarily locked</h1>
+	<p>We detected several failed sign-in attempts on your Intercord account, so we have locked it until %s.</p>
+	<p>If this was you, you can try again after that time or reset your password.</p>
+	<p>If this was not you, we recommend resetting your password once the lock expires.</p>
+	`, lockedUntil.UTC().Format(time.RFC1123))
+
+	return s.SendEmail(to, subject, body)
+}
+
+func (s *EmailService) SendEmailChangeConfirmation(to, token string, baseURL string) error {
+	subject := "Confirm your new email address"
+	confirmationLink := fmt.Sprintf("%s/auth/confirm-email-change?token=%s", baseURL, token)
+	body := fmt.Sprintf(`
+	<h1>Confirm your new email address</h1>
+	<p>You have requested to change the email address of your Intercord account to this address. Please click the link below to confirm the change:</p>
+	<p><a href="%s">Confirm Email Change</a></p>
+	<p>If you did not request this change, please ignore this email.</p>
+	`, confirmationLink)
+
+	return s.SendEmail(to, subject, body)
+}
+
+func (s *EmailService) SendEmailChangedNotice(to, newEmail string) error {
+	subject := "Your email address was changed"
+	body := fmt.Sprintf(`
+	<h1>Your email address was changed</h1>
+	<p>The email address of your Intercord account has been changed to %s.</p>
+	<p>If you did not make this change, please contact support immediately.</p>
+	`, template.HTMLEscapeString(newEmail))
+
+	return s.SendEmail(to, subject, body)
+}
+
+func (s *EmailService) SendDataExportReadyEmail(to, downloadLink string, expiresAt time.Time) error {
+	subject := "Your data export is ready"
+	body := fmt.Sprintf(`
+	<h1>Your data export is ready</h1>
+	<p>The export of your Intercord account data you requested is ready to download:</p>
+	<p><a href="%s">Download Export</a></p>
+	<p>This link expires on %s.</p>
+	<p>If you did not request this export, please change your password.</p>
+	`, downloadLink, expiresAt.UTC().Format(time.RFC1123))
+
+	return s.SendEmail(to, subject, body)
+}
+
+func (s *EmailService) SendJoinRequestEmail(to, requesterName, teamName, reviewLink string) error {
+	subject := fmt.Sprintf("New request to join %s", teamName)
+	body := fmt.Sprintf(`
+	<h1>New Join Request</h1>
+	<p>%s has asked to join the %s team on Intercord.</p>
+	<p><a href="%s">Review Request</a></p>
+	`, template.HTMLEscapeString(requesterName), template.HTMLEscapeString(teamName), reviewLink)
+
+	return s.SendEmail(to, subject, body)
+}
+
+func (s *EmailService) SendJoinRequestDecisionEmail(to, teamName string, approved bool) error {
+	decision, heading := "declined", "Declined"
+	if approved {
+		decision, heading = "approved", "Approved"
+	}
+
+	subject := fmt.Sprintf("Your request to join %s was %s", teamName, decision)
+	body := fmt.Sprintf(`
+	<h1>Join Request %s</h1>
+	<p>Your request to join the %s team on Intercord was %s.</p>
+	`, heading, template.HTMLEscapeString(teamName), decision)
+
+	return s.SendEmail(to, subject, body)
+}
@@ -0,0 +1,64 @@
+package services
+
+import "github.com/open-move/intercord/internal/move"
+
+// EventMatcher routes emitted event types to the subscriptions whose patterns
+// select them. Patterns are indexed by package, module and struct name, so an
+// event is only compared with the patterns that can possibly match it.
+type EventMatcher struct {
+	packages map[string][]matcherEntry
+	modules  map[string][]matcherEntry
+	structs  map[string][]matcherEntry
+}
+
+type matcherEntry struct {
+	subscriptionID int64
+	pattern        *move.EventPattern
+}
+
+func NewEventMatcher() *EventMatcher {
+	return &EventMatcher{
+		packages: make(map[string][]matcherEntry),
+		modules:  make(map[string][]matcherEntry),
+		structs:  make(map[string][]matcherEntry),
+	}
+}
+
+func (m *EventMatcher) Add(subscriptionID int64, pattern *move.EventPattern) {
+	entry := matcherEntry{subscriptionID: subscriptionID, pattern: pattern}
+
+	switch pattern.Kind() {
+	case move.PatternPackage:
+		m.packages[pattern.Address] = append(m.packages[pattern.Address], entry)
+	case move.PatternModule:
+		key := pattern.Address + "::" + pattern.Module
+		m.modules[key] = append(m.modules[key], entry)
+	default:
+		key := pattern.Address + "::" + pattern.Module + "::" + pattern.Name
+		m.structs[key] = append(m.structs[key], entry)
+	}
+}
+
+// Match returns the subscriptions that receive an event of the given type.
+func (m *EventMatcher) Match(tag *move.StructTag) []int64 {
+	module := tag.Address + "::" + tag.Module
+
+	var ids []int64
+	for _, bucket := range [][]matcherEntry{
+		m.packages[tag.Address],
+		m.modules[module],
+		m.structs[module+"::"+tag.Name],
+	} {
+		for _, entry := range bucket {
+			if entry.pattern.Matches(tag) {
+				ids = append(ids, entry.subscriptionID)
+			}
+		}
+	}
+
+	return ids
+}
+
+func (m *EventMatcher) Empty() bool {
+	return len(m.packages) == 0 && len(m.modules) == 0 && len(m.structs) == 0
+}
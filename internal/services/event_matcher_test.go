@@ -0,0 +1,85 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/open-move/intercord/internal/move"
+)
+
+func TestEventMatcher(t *testing.T) {
+	patterns := map[int64]string{
+		1: "0x2",
+		2: "0x2::coin",
+		3: "0x2::coin::CoinCreated",
+		4: "0x2::coin::CoinCreated<0x2::sui::SUI>",
+		5: "0x2::coin::CoinCreated<*>",
+		6: "0x3::pool::Swap<*, 0x2::sui::SUI>",
+		7: "0x0002::coin::*",
+	}
+
+	matcher := NewEventMatcher()
+	if !matcher.Empty() {
+		t.Fatal("new matcher is not empty")
+	}
+
+	for id, input := range patterns {
+		pattern, err := move.ParseEventPattern(input)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", input, err)
+		}
+		matcher.Add(id, pattern)
+	}
+
+	tests := []struct {
+		event string
+		want  []int64
+	}{
+		{"0x2::coin::CoinCreated<0x2::sui::SUI>", []int64{1, 2, 3, 4, 5, 7}},
+		{"0x2::coin::CoinCreated<0x3::usdc::USDC>", []int64{1, 2, 3, 5, 7}},
+		{"0x2::coin::CoinCreated", []int64{1, 2, 3, 7}},
+		{"0x2::coin::CoinDestroyed", []int64{1, 2, 7}},
+		{"0x2::balance::Supply", []int64{1}},
+		{"0x3::pool::Swap<0x3::usdc::USDC, 0x2::sui::SUI>", []int64{6}},
+		{"0x3::pool::Swap<0x2::sui::SUI, 0x3::usdc::USDC>", nil},
+		{"0x3::pool::Deposit", nil},
+		{"0x4::coin::CoinCreated", nil},
+	}
+
+	for _, tt := range tests {
+		tag, err := move.ParseStructTag(tt.event)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.event, err)
+		}
+
+		got := matcher.Match(tag)
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestHasWildcard(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"0x2::coin::Coin<0x2::sui::SUI>", false},
+		{"0x2::m::S<u8, vector<address>>", false},
+		{"0x2::m::S<*>", true},
+		{"0x2::m::S<u8, vector<*>>", true},
+		{"0x2::m::S<0x1::m::T<*>>", true},
+	}
+
+	for _, tt := range tests {
+		pattern, err := move.ParseEventPattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.pattern, err)
+		}
+
+		if got := hasWildcard(pattern.TypeParams); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/open-move/intercord/internal/config"
+	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/move"
+	"github.com/open-move/intercord/internal/sui"
+)
+
+const (
+	EventTypeCheckReject = "reject"
+	EventTypeCheckWarn   = "warn"
+	EventTypeCheckOff    = "off"
+)
+
+// EventTypeService checks subscription event types against the modules
+// published on chain.
+type EventTypeService struct {
+	client *sui.Client
+	config *config.SuiConfig
+
+	mu      sync.Mutex
+	modules map[string]cachedModule
+}
+
+type cachedModule struct {
+	module    *sui.NormalizedModule
+	expiresAt time.Time
+}
+
+func NewEventTypeService(client *sui.Client, config *config.SuiConfig) *EventTypeService {
+	return &EventTypeService{
+		client:  client,
+		config:  config,
+		modules: make(map[string]cachedModule),
+	}
+}
+
+// Resolve checks that the package, module or struct an event pattern names
+// exists on chain and, for struct patterns, returns the struct's field layout.
+// A struct that can't be emitted as an event or is given the wrong number of
+// type arguments is rejected too. Problems are only logged when checks are set
+// to warn, and if the node can't be reached the pattern is accepted without a
+// layout.
+func (s *EventTypeService) Resolve(ctx context.Context, pattern *move.EventPattern) ([]models.EventField, error) {
+	definition, err := s.resolve(ctx, pattern, "event type", "events", "copy", "drop")
+	if err != nil || definition == nil {
+		return nil, err
+	}
+
+	tag := pattern.StructTag()
+	args := make([]string, len(tag.TypeParams))
+	for i, param := range tag.TypeParams {
+		if !param.Wildcard {
+			args[i] = param.String()
+		}
+	}
+
+	fields := make([]models.EventField, 0, len(definition.Fields))
+	for _, field := range definition.Fields {
+		fields = append(fields, models.EventField{
+			Name: field.Name,
+			Type: field.Type.Render(args),
+		})
+	}
+
+	return fields, nil
+}
+
+// ResolveObjectType checks an object type pattern the same way, requiring
+// structs to have the key ability objects need.
+func (s *EventTypeService) ResolveObjectType(ctx context.Context, pattern *move.EventPattern) error {
+	_, err := s.resolve(ctx, pattern, "object type", "objects", "key")
+	return err
+}
+
+// resolve looks up what a pattern names and returns the struct definition for
+// struct patterns.
+func (s *EventTypeService) resolve(ctx context.Context, pattern *move.EventPattern, what, use string, abilities ...string) (*sui.NormalizedStruct, error) {
+	if s.config.EventTypeCheck == EventTypeCheckOff {
+		return nil, nil
+	}
+
+	if pattern.Kind() == move.PatternPackage {
+		err := s.loadPackage(ctx, pattern.Address)
+		if err != nil {
+			return nil, s.lookupFailed(pattern, what, err, fmt.Errorf("package %s was not found on chain", pattern.Address))
+		}
+		return nil, nil
+	}
+
+	module, err := s.module(ctx, pattern.Address, pattern.Module)
+	if err != nil {
+		return nil, s.lookupFailed(pattern, what, err, fmt.Errorf("module %s::%s was not found on chain", pattern.Address, pattern.Module))
+	}
+
+	if pattern.Kind() == move.PatternModule {
+		return nil, nil
+	}
+
+	tag := pattern.StructTag()
+	definition, ok := module.Structs[tag.Name]
+	if !ok {
+		return nil, s.problem(pattern, what, fmt.Errorf("struct %s was not found in module %s", tag.Name, tag.Module))
+	}
+
+	var missing []string
+	for _, ability := range abilities {
+		if !definition.Abilities.Has(ability) {
+			missing = append(missing, ability)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, s.problem(pattern, what, fmt.Errorf("struct %s lacks the %s abilities %s need", tag.Name, strings.Join(missing, " and "), use))
+	}
+
+	// Without type arguments the pattern matches every instantiation.
+	if tag.TypeParams != nil && len(tag.TypeParams) != len(definition.TypeParameters) {
+		return nil, s.problem(pattern, what, fmt.Errorf("struct %s takes %d type arguments, got %d", tag.Name, len(definition.TypeParameters), len(tag.TypeParams)))
+	}
+
+	return &definition, nil
+}
+
+// lookupFailed reports a missing package or module, but lets the pattern
+// through when the node couldn't answer at all.
+func (s *EventTypeService) lookupFailed(pattern *move.EventPattern, what string, err, notFound error) error {
+	var rpcErr *sui.RPCError
+	if !errors.As(err, &rpcErr) {
+		log.Printf("Failed to verify %s %s: %v", what, pattern, err)
+		return nil
+	}
+	return s.problem(pattern, what, notFound)
+}
+
+func (s *EventTypeService) problem(pattern *move.EventPattern, what string, err error) error {
+	if s.config.EventTypeCheck == EventTypeCheckWarn {
+		log.Printf("Accepting unverified %s %s: %v", what, pattern, err)
+		return nil
+	}
+	return fmt.Errorf("invalid %s: %w", what, err)
+}
+
+// loadPackage fetches every module of a package into the cache.
+func (s *EventTypeService) loadPackage(ctx context.Context, address string) error {
+	modules, err := s.client.GetNormalizedMoveModulesByPackage(ctx, address)
+	if err != nil {
+		return err
+	}
+
+	for name, module := range modules {
+		s.store(address+"::"+name, &module)
+	}
+	return nil
+}
+
+// maxCachedModules bounds the module cache. When it is full, the entry closest
+// to expiring makes room.
+const maxCachedModules = 1000
+
+// module returns a normalized module, from the cache when possible. Published
+// modules never change; entries expire so unused modules don't stay forever.
+func (s *EventTypeService) module(ctx context.Context, address, name string) (*sui.NormalizedModule, error) {
+	key := address + "::" + name
+	now := time.Now()
+
+	s.mu.Lock()
+	cached, ok := s.modules[key]
+	s.mu.Unlock()
+
+	if ok && cached.expiresAt.After(now) {
+		return cached.module, nil
+	}
+
+	module, err := s.client.GetNormalizedMoveModule(ctx, address, name)
+	if err != nil {
+		return nil, err
+	}
+
+	s.store(key, module)
+	return module, nil
+}
+
+func (s *EventTypeService) store(key string, module *sui.NormalizedModule) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, entry := range s.modules {
+		if !entry.expiresAt.After(now) {
+			delete(s.modules, k)
+		}
+	}
+
+	if _, ok := s.modules[key]; !ok && len(s.modules) >= maxCachedModules {
+		oldest := ""
+		for k, entry := range s.modules {
+			if oldest == "" || entry.expiresAt.Before(s.modules[oldest].expiresAt) {
+				oldest = k
+			}
+		}
+		delete(s.modules, oldest)
+	}
+
+	s.modules[key] = cachedModule{module: module, expiresAt: now.Add(s.config.ModuleCacheTTL)}
+}
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/open-move/intercord/internal/config"
+	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/utils"
+)
+
+const exportGenerationTimeout = 10 * time.Minute
+
+type ExportService struct {
+	db           *bun.DB
+	config       *config.ExportConfig
+	secret       string
+	emailService *EmailService
+}
+
+func NewExportService(db *bun.DB, config *config.ExportConfig, secret string, emailService *EmailService) *ExportService {
+	return &ExportService{
+		db:           db,
+		config:       config,
+		secret:       secret,
+		emailService: emailService,
+	}
+}
+
+func (s *ExportService) Request(ctx context.Context, userID int64, baseURL string) (*models.DataExport, error) {
+	inProgress, err := s.db.NewSelect().
+		Model((*models.DataExport)(nil)).
+		Where("user_id = ?", userID).
+		Where("status IN (?)", bun.In([]models.DataExportStatus{models.DataExportStatusPending, models.DataExportStatusProcessing})).
+		Where("created_at > ?", time.Now().Add(-exportGenerationTimeout)).
+		Exists(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if inProgress {
+		return nil, errors.New("an export is already being prepared")
+	}
+
+	export := &models.DataExport{
+		UserID: userID,
+		Status: models.DataExportStatusPending,
+	}
+
+	_, err = s.db.NewInsert().Model(export).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.generate(export.ID, userID, baseURL)
+
+	return export, nil
+}
+
+func (s *ExportService) List(ctx context.Context, userID int64) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := s.db.NewSelect().
+		Model(&exports).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return exports, nil
+}
+
+func (s *ExportService) Open(ctx context.Context, id int64, expires, signature string) (*models.DataExport, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return nil, errors.New("download link has expired")
+	}
+
+	if !utils.VerifySignature(s.secret, exportSignaturePayload(id, expiresAt), signature) {
+		return nil, errors.New("invalid download link")
+	}
+
+	export := new(models.DataExport)
+	err = s.db.NewSelect().
+		Model(export).
+		Where("id = ?", id).
+		Where("status = ?", models.DataExportStatusReady).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, errors.New("export not found")
+	}
+
+	return export, nil
+}
+
+func (s *ExportService) PurgeExpired(ctx context.Context) error {
+	// Exports still in progress after the generation timeout were abandoned,
+	// typically by a restart.
+	_, err := s.db.NewUpdate().
+		Model((*models.DataExport)(nil)).
+		Set("status = ?", models.DataExportStatusFailed).
+		Set("error_message = ?", "export timed out").
+		Set("expires_at = ?", time.Now().Add(s.config.LinkTTL)).
+		Where("status IN (?)", bun.In([]models.DataExportStatus{models.DataExportStatusPending, models.DataExportStatusProcessing})).
+		Where("created_at < ?", time.Now().Add(-exportGenerationTimeout)).
+		Exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	var exports []models.DataExport
+	err = s.db.NewSelect().
+		Model(&exports).
+		Where("expires_at < ?", time.Now()).
+		Scan(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		if export.FilePath != "" {
+			if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+
+		_, err = s.db.NewDelete().Model((*models.DataExport)(nil)).Where("id = ?", export.ID).Exec(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *ExportService) generate(exportID, userID int64, baseURL string) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportGenerationTimeout)
+	defer cancel()
+
+	_, err := s.db.NewUpdate().
+		Model((*models.DataExport)(nil)).
+		Set("status = ?", models.DataExportStatusProcessing).
+		Where("id = ?", exportID).
+		Exec(ctx)
+
+	if err != nil {
+		log.Printf("Failed to start data export %d: %v", exportID, err)
+		return
+	}
+
+	user, path, err := s.writeArchive(ctx, exportID, userID)
+	if err != nil {
+		log.Printf("Failed to generate data export %d: %v", exportID, err)
+		_, err = s.db.NewUpdate().
+			Model((*models.DataExport)(nil)).
+			Set("status = ?", models.DataExportStatusFailed).
+			Set("error_message = ?", err.Error()).
+			Set("expires_at = ?", time.Now().Add(s.config.LinkTTL)).
+			Where("id = ?", exportID).
+			Exec(ctx)
+
+		if err != nil {
+			log.Printf("Failed to mark data export %d as failed: %v", exportID, err)
+		}
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.config.LinkTTL)
+
+	_, err = s.db.NewUpdate().
+		Model((*models.DataExport)(nil)).
+		Set("status = ?", models.DataExportStatusReady).
+		Set("file_path = ?", path).
+		Set("completed_at = ?", now).
+		Set("expires_at = ?", expiresAt).
+		Where("id = ?", exportID).
+		Exec(ctx)
+
+	if err != nil {
+		log.Printf("Failed to complete data export %d: %v", exportID, err)
+		return
+	}
+
+	signature := utils.Sign(s.secret, exportSignaturePayload(exportID, expiresAt.Unix()))
+	downloadLink := fmt.Sprintf("%s/exports/%d/download?expires=%d&signature=%s", baseURL, exportID, expiresAt.Unix(), signature)
+
+	if err := s.emailService.SendDataExportReadyEmail(user.Email, downloadLink, expiresAt); err != nil {
+		log.Printf("Failed to send data export %d link: %v", exportID, err)
+	}
+}
+
+func (s *ExportService) writeArchive(ctx context.Context, exportID, userID int64) (*models.User, string, error) {
+	user := new(models.User)
+	if err := s.db.NewSelect().Model(user).Where("id = ?", userID).Scan(ctx); err != nil {
+		return nil, "", err
+	}
+
+	var memberships []models.TeamMembership
+	err := s.db.NewSelect().
+		Model(&memberships).
+		Relation("Team").
+		Where("tm.user_id = ?", userID).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	var subscriptions []models.Subscription
+	err = s.db.NewSelect().
+		Model(&subscriptions).
+		Relation("Channels").
+		Where("user_id = ?", userID).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	var channels []models.Channel
+	err = s.db.NewSelect().
+		Model(&channels).
+		Where("user_id = ?", userID).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	for i := range channels {
+		channels[i].Config = RedactChannelConfig(channels[i].Config)
+	}
+
+	var notifications []models.Notification
+	err = s.db.NewSelect().
+		Model(&notifications).
+		Where("subscription_id IN (?)", s.db.NewSelect().Model((*models.Subscription)(nil)).Column("id").Where("user_id = ?", userID)).
+		Order("created_at ASC").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	var sessions []models.Session
+	err = s.db.NewSelect().
+		Model(&sessions).
+		Where("user_id = ?", userID).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := os.MkdirAll(s.config.Dir, 0o700); err != nil {
+		return nil, "", err
+	}
+
+	path := filepath.Join(s.config.Dir, fmt.Sprintf("export-%d-%d.zip", userID, exportID))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	entries := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", user},
+		{"team_memberships.json", memberships},
+		{"subscriptions.json", subscriptions},
+		{"channels.json", channels},
+		{"notifications.json", notifications},
+		{"sessions.json", sessions},
+	}
+
+	for _, entry := range entries {
+		writer, err := archive.Create(entry.name)
+		if err != nil {
+			return nil, "", err
+		}
+
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(entry.data); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return user, path, nil
+}
+
+func exportSignaturePayload(exportID, expiresAt int64) string {
+	return fmt.Sprintf("export:%d:%d", exportID, expiresAt)
+}
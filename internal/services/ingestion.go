@@ -0,0 +1,615 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/open-move/intercord/internal/config"
+	"github.com/open-move/intercord/internal/filter"
+	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/move"
+	"github.com/open-move/intercord/internal/sui"
+)
+
+// errCursorMoved means another instance ingested the same page first.
+var errCursorMoved = errors.New("ingestion cursor moved")
+
+type IngestionService struct {
+	db           *bun.DB
+	client       *sui.Client
+	config       *config.SuiConfig
+	quotaService *QuotaService
+
+	mu                   sync.Mutex
+	packageModules       map[string][]string
+	balancesReconciledAt time.Time
+}
+
+func NewIngestionService(db *bun.DB, client *sui.Client, config *config.SuiConfig, quotaService *QuotaService) *IngestionService {
+	return &IngestionService{
+		db:             db,
+		client:         client,
+		config:         config,
+		quotaService:   quotaService,
+		packageModules: make(map[string][]string),
+	}
+}
+
+// eventRouter is the set of active event subscriptions at the start of a poll.
+type eventRouter struct {
+	subscriptions map[int64]*models.Subscription
+	filters       map[int64]*filter.Program
+	channels      map[int64][]int64
+	streams       map[string]*eventStream
+}
+
+// eventStream reads the events matching one node filter. Full nodes only
+// answer single filters, so each event module, type or sender is read on its
+// own, with its own cursor. A subscription is only matched against the events
+// of the streams it was assigned to, so it never sees an event twice.
+type eventStream struct {
+	name    string
+	filter  sui.EventFilter
+	matcher *EventMatcher
+
+	// since is when the stream's oldest subscription was created, which is
+	// as far back as the stream reads when it has no cursor yet.
+	since time.Time
+}
+
+func (r *eventRouter) add(name string, filter sui.EventFilter, subscription *models.Subscription, pattern *move.EventPattern) {
+	stream, ok := r.streams[name]
+	if !ok {
+		stream = &eventStream{name: name, filter: filter, matcher: NewEventMatcher(), since: subscription.CreatedAt}
+		r.streams[name] = stream
+	}
+	if subscription.CreatedAt.Before(stream.since) {
+		stream.since = subscription.CreatedAt
+	}
+	stream.matcher.Add(subscription.ID, pattern)
+}
+
+func (r *eventRouter) sorted() []*eventStream {
+	names := make([]string, 0, len(r.streams))
+	for name := range r.streams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ordered := make([]*eventStream, 0, len(names))
+	for _, name := range names {
+		ordered = append(ordered, r.streams[name])
+	}
+	return ordered
+}
+
+// PollEvents reads the events emitted since the last poll and queues a
+// notification for every channel of every subscription they match.
+func (s *IngestionService) PollEvents(ctx context.Context) error {
+	router, err := s.eventRouter(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, stream := range router.sorted() {
+		err := s.pollEventStream(ctx, stream.name, stream.filter, stream.since, func(events []sui.Event, from, to *models.IngestionCursor) error {
+			return s.ingestEvents(ctx, router, stream, events, from, to)
+		})
+		if err != nil {
+			log.Printf("Polling %s failed: %v", stream.name, err)
+		}
+	}
+
+	return nil
+}
+
+// pollEventStream reads the events matching filter since a stream's cursor
+// and hands them to ingest a page at a time. A stream without a cursor starts
+// with the first event at or after since.
+func (s *IngestionService) pollEventStream(ctx context.Context, stream string, filter sui.EventFilter, since time.Time, ingest func(events []sui.Event, from, to *models.IngestionCursor) error) error {
+	from, err := s.cursor(ctx, stream)
+	if err != nil {
+		return err
+	}
+
+	after := from
+	if from == nil {
+		if after, err = s.startingEvent(ctx, stream, filter, since); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < s.config.PollMaxPages; i++ {
+		var cursor *sui.EventID
+		if after != nil {
+			cursor = &sui.EventID{TxDigest: after.TxDigest, EventSeq: after.EventSeq}
+		}
+
+		page, err := s.client.QueryEvents(ctx, filter, cursor, s.config.PollPageSize, false)
+		if err != nil || len(page.Data) == 0 {
+			return err
+		}
+
+		next := page.NextCursor
+		if next == nil {
+			next = &page.Data[len(page.Data)-1].ID
+		}
+
+		to := eventPosition(*next)
+		if err := ingest(page.Data, from, to); err != nil {
+			return ignoreCursorMoved(err)
+		}
+
+		if !page.HasNextPage {
+			return nil
+		}
+		from, after = to, to
+	}
+
+	return nil
+}
+
+// startingEvent looks back from the newest matching event for the last one
+// emitted before since, which a new stream reads after. It is nil when there
+// is none, so the stream reads from the first event. The search is bounded
+// like a poll; older events past the bound are skipped.
+func (s *IngestionService) startingEvent(ctx context.Context, stream string, filter sui.EventFilter, since time.Time) (*models.IngestionCursor, error) {
+	var cursor *sui.EventID
+	for i := 0; i < s.config.PollMaxPages; i++ {
+		page, err := s.client.QueryEvents(ctx, filter, cursor, s.config.PollPageSize, true)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range page.Data {
+			if emitted := event.Timestamp(); !emitted.IsZero() && emitted.Before(since) {
+				return eventPosition(event.ID), nil
+			}
+		}
+
+		if !page.HasNextPage || len(page.Data) == 0 {
+			return nil, nil
+		}
+		cursor = &page.Data[len(page.Data)-1].ID
+	}
+
+	log.Printf("Starting %s %d pages back, skipping its earlier events since %s", stream, s.config.PollMaxPages, since.Format(time.RFC3339))
+	return eventPosition(*cursor), nil
+}
+
+func eventPosition(id sui.EventID) *models.IngestionCursor {
+	return &models.IngestionCursor{TxDigest: id.TxDigest, EventSeq: id.EventSeq}
+}
+
+func (s *IngestionService) eventRouter(ctx context.Context) (*eventRouter, error) {
+	subscriptions, err := s.activeSubscriptions(ctx, models.SubscriptionKindEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	router := &eventRouter{
+		subscriptions: make(map[int64]*models.Subscription, len(subscriptions)),
+		filters:       make(map[int64]*filter.Program),
+		streams:       make(map[string]*eventStream),
+	}
+
+	ids := make([]int64, 0, len(subscriptions))
+	packages := make(map[string][]string)
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		pattern, err := move.ParseEventPattern(subscription.EventType)
+		if err != nil {
+			log.Printf("Skipping subscription %d with invalid event type %q: %v", subscription.ID, subscription.EventType, err)
+			continue
+		}
+
+		var program *filter.Program
+		if subscription.Filter != "" {
+			program, err = filter.Compile(subscription.Filter, subscription.EventFields)
+			if err != nil {
+				log.Printf("Skipping subscription %d with invalid filter: %v", subscription.ID, err)
+				continue
+			}
+		}
+
+		switch {
+		case pattern.Kind() == move.PatternPackage && len(subscription.Senders) > 0:
+			// Reading what the senders emit avoids a stream per module.
+			for _, sender := range subscription.Senders {
+				router.add("events:sender:"+sender, sui.SenderEvents(sender), subscription, pattern)
+			}
+		case pattern.Kind() == move.PatternPackage:
+			modules, err := s.modulesOf(ctx, pattern.Address)
+			if err != nil {
+				log.Printf("Skipping subscription %d this poll, listing the modules of %s failed: %v", subscription.ID, pattern.Address, err)
+				continue
+			}
+			packages[pattern.Address] = modules
+
+			for _, module := range modules {
+				router.add(moduleStream(pattern.Address, module), sui.ModuleEvents(pattern.Address, module), subscription, pattern)
+			}
+		case pattern.Kind() == move.PatternType:
+			if tag := pattern.StructTag(); !hasWildcard(tag.TypeParams) {
+				router.add("events:type:"+tag.String(), sui.EventTypeEvents(tag.String()), subscription, pattern)
+				break
+			}
+			fallthrough
+		default:
+			router.add(moduleStream(pattern.Address, pattern.Module), sui.ModuleEvents(pattern.Address, pattern.Module), subscription, pattern)
+		}
+
+		if program != nil {
+			router.filters[subscription.ID] = program
+		}
+		router.subscriptions[subscription.ID] = subscription
+		ids = append(ids, subscription.ID)
+	}
+
+	// Only keep the module lists of packages still watched.
+	s.mu.Lock()
+	s.packageModules = packages
+	s.mu.Unlock()
+
+	router.channels, err = s.subscriptionChannels(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return router, nil
+}
+
+func moduleStream(address, module string) string {
+	return "events:module:" + address + "::" + module
+}
+
+func hasWildcard(params []move.TypeTag) bool {
+	for _, param := range params {
+		switch {
+		case param.Wildcard:
+			return true
+		case param.Vector != nil && hasWildcard([]move.TypeTag{*param.Vector}):
+			return true
+		case param.Struct != nil && hasWildcard(param.Struct.TypeParams):
+			return true
+		}
+	}
+	return false
+}
+
+// modulesOf lists the modules of a package, which never change once it is
+// published. Packages have no event filter of their own, so their patterns are
+// read module by module.
+func (s *IngestionService) modulesOf(ctx context.Context, address string) ([]string, error) {
+	s.mu.Lock()
+	modules, ok := s.packageModules[address]
+	s.mu.Unlock()
+
+	if ok {
+		return modules, nil
+	}
+
+	bytecode, err := s.client.GetPackageModules(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	for name := range bytecode {
+		modules = append(modules, name)
+	}
+	sort.Strings(modules)
+
+	s.mu.Lock()
+	s.packageModules[address] = modules
+	s.mu.Unlock()
+
+	return modules, nil
+}
+
+// matchesSource applies a subscription's sender, emitter and transaction kind
+// filters to an event. The node only ever gets one filter per query, so these
+// are always checked here.
+func matchesSource(subscription *models.Subscription, event sui.Event, kinds map[string]string) bool {
+	if len(subscription.Senders) > 0 {
+		sender, _ := move.NormalizeAddress(event.Sender)
+		if !slices.Contains(subscription.Senders, sender) {
+			return false
+		}
+	}
+
+	if subscription.EmitterPackage != "" {
+		emitter, _ := move.NormalizeAddress(event.PackageID)
+		if emitter != subscription.EmitterPackage {
+			return false
+		}
+	}
+
+	if subscription.EmitterModule != "" && event.TransactionModule != subscription.EmitterModule {
+		return false
+	}
+
+	if len(subscription.TransactionKinds) > 0 && !slices.Contains(subscription.TransactionKinds, kinds[event.ID.TxDigest]) {
+		return false
+	}
+
+	return true
+}
+
+// transactionKinds looks up the kind of every transaction in a page that a
+// subscription filtering on transaction kinds could receive, since events
+// don't carry it.
+func (s *IngestionService) transactionKinds(ctx context.Context, router *eventRouter, stream *eventStream, events []sui.Event) (map[string]string, error) {
+	var digests []string
+	seen := make(map[string]bool)
+
+	for _, event := range events {
+		if seen[event.ID.TxDigest] {
+			continue
+		}
+
+		tag, err := move.ParseStructTag(event.Type)
+		if err != nil {
+			continue
+		}
+
+		for _, subscriptionID := range stream.matcher.Match(tag) {
+			if len(router.subscriptions[subscriptionID].TransactionKinds) > 0 {
+				seen[event.ID.TxDigest] = true
+				digests = append(digests, event.ID.TxDigest)
+				break
+			}
+		}
+	}
+
+	kinds := make(map[string]string, len(digests))
+	if len(digests) == 0 {
+		return kinds, nil
+	}
+
+	blocks, err := s.client.GetTransactionBlocks(ctx, digests)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, block := range blocks {
+		kinds[block.Digest] = block.Kind()
+	}
+
+	return kinds, nil
+}
+
+func (s *IngestionService) ingestEvents(ctx context.Context, router *eventRouter, stream *eventStream, events []sui.Event, from, to *models.IngestionCursor) error {
+	kinds, err := s.transactionKinds(ctx, router, stream, events)
+	if err != nil {
+		return err
+	}
+
+	return s.commit(ctx, stream.name, from, to, func(queue *notificationQueue) error {
+		for _, event := range events {
+			tag, err := move.ParseStructTag(event.Type)
+			if err != nil {
+				log.Printf("Skipping event %s/%s with unparseable type %q: %v", event.ID.TxDigest, event.ID.EventSeq, event.Type, err)
+				continue
+			}
+
+			payload, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+
+			for _, subscriptionID := range stream.matcher.Match(tag) {
+				subscription := router.subscriptions[subscriptionID]
+				if emitted := event.Timestamp(); !emitted.IsZero() && emitted.Before(subscription.CreatedAt) {
+					continue
+				}
+
+				if !matchesSource(subscription, event, kinds) {
+					continue
+				}
+
+				if program := router.filters[subscriptionID]; program != nil {
+					matched, err := program.Matches(event)
+					if err != nil {
+						log.Printf("Filter of subscription %d failed on event %s/%s: %v", subscriptionID, event.ID.TxDigest, event.ID.EventSeq, err)
+					}
+					if !matched {
+						continue
+					}
+				}
+
+				if err := queue.add(subscription, router.channels[subscriptionID], payload); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *IngestionService) activeSubscriptions(ctx context.Context, kinds ...models.SubscriptionKind) ([]models.Subscription, error) {
+	var subscriptions []models.Subscription
+	err := s.db.NewSelect().
+		Model(&subscriptions).
+		Where("kind IN (?)", bun.In(kinds)).
+		Where("is_active = ?", true).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+// subscriptionChannels maps each subscription to the channels it delivers to.
+func (s *IngestionService) subscriptionChannels(ctx context.Context, subscriptionIDs []int64) (map[int64][]int64, error) {
+	channels := make(map[int64][]int64)
+	if len(subscriptionIDs) == 0 {
+		return channels, nil
+	}
+
+	var links []models.SubscriptionChannel
+	err := s.db.NewSelect().
+		Model(&links).
+		Where("subscription_id IN (?)", bun.In(subscriptionIDs)).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, link := range links {
+		channels[link.SubscriptionID] = append(channels[link.SubscriptionID], link.ChannelID)
+	}
+
+	return channels, nil
+}
+
+func (s *IngestionService) cursor(ctx context.Context, stream string) (*models.IngestionCursor, error) {
+	cursor := new(models.IngestionCursor)
+	err := s.db.NewSelect().Model(cursor).Where("stream = ?", stream).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return cursor, nil
+}
+
+// notificationQueue collects the notifications for one page of a stream,
+// metering each against its team's monthly quota.
+type notificationQueue struct {
+	ctx           context.Context
+	tx            bun.Tx
+	quotaService  *QuotaService
+	exhausted     map[int64]bool
+	notifications []models.Notification
+}
+
+func (q *notificationQueue) add(subscription *models.Subscription, channels []int64, payload []byte) error {
+	for _, channelID := range channels {
+		if teamID := subscription.TeamID; teamID != nil {
+			if q.exhausted[*teamID] {
+				continue
+			}
+
+			err := q.quotaService.MeterNotification(q.ctx, q.tx, *teamID)
+			if errors.Is(err, ErrNotificationQuotaExceeded) {
+				log.Printf("Dropping notifications for team %d: %v", *teamID, err)
+				q.exhausted[*teamID] = true
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		q.notifications = append(q.notifications, models.Notification{
+			SubscriptionID: subscription.ID,
+			ChannelID:      channelID,
+			EventPayload:   string(payload),
+			Status:         models.NotificationStatusPending,
+		})
+	}
+
+	return nil
+}
+
+// commit queues the notifications for a page of a stream and moves the
+// stream's cursor from one position to the next in the same transaction, so a
+// page is never delivered twice, even with several instances polling.
+func (s *IngestionService) commit(ctx context.Context, stream string, from, to *models.IngestionCursor, fill func(queue *notificationQueue) error) error {
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := s.moveCursor(ctx, tx, stream, from, to); err != nil {
+			return err
+		}
+
+		return s.enqueue(ctx, tx, fill)
+	})
+}
+
+// enqueue inserts the notifications fill queues within tx.
+func (s *IngestionService) enqueue(ctx context.Context, tx bun.Tx, fill func(queue *notificationQueue) error) error {
+	queue := &notificationQueue{
+		ctx:          ctx,
+		tx:           tx,
+		quotaService: s.quotaService,
+		exhausted:    make(map[int64]bool),
+	}
+
+	if err := fill(queue); err != nil {
+		return err
+	}
+
+	if len(queue.notifications) == 0 {
+		return nil
+	}
+
+	_, err := tx.NewInsert().Model(&queue.notifications).Exec(ctx)
+	return err
+}
+
+func (s *IngestionService) moveCursor(ctx context.Context, tx bun.Tx, stream string, from, to *models.IngestionCursor) error {
+	var (
+		result sql.Result
+		err    error
+	)
+
+	if from == nil {
+		result, err = tx.NewInsert().
+			Model(&models.IngestionCursor{
+				Stream:    stream,
+				TxDigest:  to.TxDigest,
+				EventSeq:  to.EventSeq,
+				UpdatedAt: time.Now(),
+			}).
+			On("CONFLICT (stream) DO NOTHING").
+			Exec(ctx)
+	} else {
+		result, err = tx.NewUpdate().
+			Model((*models.IngestionCursor)(nil)).
+			Set("tx_digest = ?", to.TxDigest).
+			Set("event_seq = ?", to.EventSeq).
+			Set("updated_at = ?", time.Now()).
+			Where("stream = ?", stream).
+			Where("tx_digest = ?", from.TxDigest).
+			Where("event_seq = ?", from.EventSeq).
+			Exec(ctx)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return errCursorMoved
+	}
+
+	return nil
+}
+
+func ignoreCursorMoved(err error) error {
+	if errors.Is(err, errCursorMoved) {
+		return nil
+	}
+	return err
+}
+
+// isRPCError reports whether the node answered with an error, such as a
+// pruned or missing object, rather than being unreachable.
+func isRPCError(err error) bool {
+	var rpcErr *sui.RPCError
+	return errors.As(err, &rpcErr)
+}
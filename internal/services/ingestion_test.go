@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/open-move/intercord/internal/config"
+	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/sui"
+)
+
+// history is a run of events, and the transactions that emitted them, one
+// second apart.
+func history(count int) ([]sui.Event, []sui.TransactionBlock) {
+	events := make([]sui.Event, count)
+	blocks := make([]sui.TransactionBlock, count)
+	for i := range events {
+		digest, timestamp := "tx"+strconv.Itoa(i), strconv.FormatInt(int64(i)*1000, 10)
+		events[i] = sui.Event{ID: sui.EventID{TxDigest: digest, EventSeq: "0"}, TimestampMs: timestamp}
+		blocks[i] = sui.TransactionBlock{Digest: digest, TimestampMs: timestamp}
+	}
+	return events, blocks
+}
+
+// fakeNode pages through events and blocks like suix_queryEvents and
+// suix_queryTransactionBlocks, ignoring the filter.
+func fakeNode(t *testing.T, events []sui.Event, blocks []sui.TransactionBlock) *sui.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("decoding request: %v", err)
+			return
+		}
+
+		var limit int
+		var descending bool
+		json.Unmarshal(request.Params[2], &limit)
+		json.Unmarshal(request.Params[3], &descending)
+
+		var result interface{}
+		switch request.Method {
+		case "suix_queryEvents":
+			var cursor *sui.EventID
+			json.Unmarshal(request.Params[1], &cursor)
+
+			data, next, more := page(events, func(event sui.Event) bool { return cursor != nil && event.ID == *cursor }, limit, descending)
+			eventPage := sui.EventPage{Data: data, HasNextPage: more}
+			if next != nil {
+				eventPage.NextCursor = &next.ID
+			}
+			result = eventPage
+		case "suix_queryTransactionBlocks":
+			var cursor *string
+			json.Unmarshal(request.Params[1], &cursor)
+
+			data, next, more := page(blocks, func(block sui.TransactionBlock) bool { return cursor != nil && block.Digest == *cursor }, limit, descending)
+			transactionPage := sui.TransactionPage{Data: data, HasNextPage: more}
+			if next != nil {
+				transactionPage.NextCursor = &next.Digest
+			}
+			result = transactionPage
+		default:
+			t.Errorf("unexpected method %s", request.Method)
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+	t.Cleanup(server.Close)
+
+	return sui.NewClient(&config.SuiConfig{RPCURL: server.URL, RequestTimeout: time.Second})
+}
+
+// page returns up to limit items after the one that is the cursor, with the
+// last of them and whether there are more.
+func page[T any](items []T, isCursor func(T) bool, limit int, descending bool) ([]T, *T, bool) {
+	ordered := slices.Clone(items)
+	if descending {
+		slices.Reverse(ordered)
+	}
+
+	start := 0
+	for i, item := range ordered {
+		if isCursor(item) {
+			start = i + 1
+		}
+	}
+
+	end := min(start+limit, len(ordered))
+	if end == start {
+		return nil, nil, false
+	}
+	return ordered[start:end], &ordered[end-1], end < len(ordered)
+}
+
+func second(s float64) time.Time {
+	return time.UnixMilli(int64(s * 1000))
+}
+
+func TestStartingEvent(t *testing.T) {
+	events, blocks := history(10)
+
+	tests := []struct {
+		name     string
+		since    time.Time
+		maxPages int
+		want     *models.IngestionCursor
+	}{
+		{"after the newest event", second(12), 5, eventPosition(events[9].ID)},
+		{"between events", second(5.5), 5, eventPosition(events[5].ID)},
+		{"at an event", second(5), 5, eventPosition(events[4].ID)},
+		{"before every event", second(-1), 5, nil},
+		{"past the page bound", second(0.5), 2, eventPosition(events[6].ID)},
+	}
+
+	for _, tt := range tests {
+		service := &IngestionService{
+			client: fakeNode(t, events, blocks),
+			config: &config.SuiConfig{PollPageSize: 2, PollMaxPages: tt.maxPages},
+		}
+
+		got, err := service.startingEvent(context.Background(), "events:test", nil, tt.since)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("%s: started after %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStartingTransaction(t *testing.T) {
+	events, blocks := history(10)
+
+	tests := []struct {
+		name     string
+		since    time.Time
+		maxPages int
+		want     string
+	}{
+		{"after the newest transaction", second(12), 5, "tx9"},
+		{"between transactions", second(5.5), 5, "tx5"},
+		{"at a transaction", second(5), 5, "tx4"},
+		{"before every transaction", second(-1), 5, ""},
+		{"past the page bound", second(0.5), 2, "tx6"},
+	}
+
+	for _, tt := range tests {
+		service := &IngestionService{
+			client: fakeNode(t, events, blocks),
+			config: &config.SuiConfig{PollPageSize: 2, PollMaxPages: tt.maxPages},
+		}
+
+		got, err := service.startingTransaction(context.Background(), &transactionStream{name: "address:from:test", since: tt.since})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+
+		var digest string
+		if got != nil {
+			digest = got.TxDigest
+		}
+		if digest != tt.want {
+			t.Errorf("%s: started after %q, want %q", tt.name, digest, tt.want)
+		}
+	}
+}
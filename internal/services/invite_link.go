@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/utils"
+)
+
+const inviteLinkTokenScope = "team-invite-link"
+
+type CreateInviteLinkInput struct {
+	Role      string     `json:"role" binding:"required,oneof=admin member"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	MaxUses   int        `json:"max_uses" binding:"min=0"`
+}
+
+func (s *TeamService) CreateInviteLink(ctx context.Context, teamID, userID int64, input CreateInviteLinkInput) (*models.TeamInviteLink, error) {
+	if input.ExpiresAt != nil && input.ExpiresAt.Before(time.Now()) {
+		return nil, errors.New("expiry must be in the future")
+	}
+
+	if err := s.checkGrant(ctx, teamID, userID, models.TeamRole(input.Role)); err != nil {
+		return nil, err
+	}
+
+	nonce, err := utils.GenerateRandomToken(24)
+	if err != nil {
+		return nil, err
+	}
+
+	link := &models.TeamInviteLink{
+		TeamID:      teamID,
+		Role:        models.TeamRole(input.Role),
+		Nonce:       nonce,
+		MaxUses:     input.MaxUses,
+		ExpiresAt:   input.ExpiresAt,
+		CreatedByID: userID,
+	}
+
+	_, err = s.db.NewInsert().Model(link).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit(ctx, s.db, teamID, userID, models.AuditInviteLinkCreated, "invite_link", link.ID, map[string]models.AuditChange{
+		"role":       {To: link.Role},
+		"max_uses":   {To: link.MaxUses},
+		"expires_at": {To: link.ExpiresAt},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	link.Token = s.inviteLinkToken(link)
+	return link, nil
+}
+
+func (s *TeamService) GetInviteLinks(ctx context.Context, teamID int64) ([]models.TeamInviteLink, error) {
+	var links []models.TeamInviteLink
+	err := s.db.NewSelect().
+		Model(&links).
+		Where("team_id = ?", teamID).
+		Order("created_at DESC").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range links {
+		if links[i].RevokedAt == nil {
+			links[i].Token = s.inviteLinkToken(&links[i])
+		}
+	}
+
+	return links, nil
+}
+
+func (s *TeamService) GetInviteLinkRedemptions(ctx context.Context, teamID, linkID int64) ([]models.TeamInviteLinkRedemption, error) {
+	var redemptions []models.TeamInviteLinkRedemption
+	err := s.db.NewSelect().
+		Model(&redemptions).
+		Relation("User").
+		Where("tilr.team_id = ?", teamID).
+		Where("tilr.link_id = ?", linkID).
+		Order("tilr.created_at DESC").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return redemptions, nil
+}
+
+func (s *TeamService) RevokeInviteLink(ctx context.Context, teamID, linkID, userID int64) error {
+	now := time.Now()
+	result, err := s.db.NewUpdate().
+		Model((*models.TeamInviteLink)(nil)).
+		Set("revoked_at = ?", now).
+		Set("updated_at = ?", now).
+		Where("id = ?", linkID).
+		Where("team_id = ?", teamID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return errors.New("active invite link not found")
+	}
+
+	return s.audit(ctx, s.db, teamID, userID, models.AuditInviteLinkRevoked, "invite_link", linkID, nil)
+}
+
+func (s *TeamService) RedeemInviteLink(ctx context.Context, token string, userID int64, ip string) (*models.TeamMembership, error) {
+	value, ok := utils.ParseSignedToken(s.secret, inviteLinkTokenScope, token)
+	if !ok {
+		return nil, errors.New("invalid invite link")
+	}
+
+	idStr, nonce, found := strings.Cut(value, ".")
+	linkID, err := strconv.ParseInt(idStr, 10, 64)
+	if !found || err != nil {
+		return nil, errors.New("invalid invite link")
+	}
+
+	link := new(models.TeamInviteLink)
+	err = s.db.NewSelect().Model(link).Where("id = ?", linkID).Scan(ctx)
+	if err != nil || subtle.ConstantTimeCompare([]byte(link.Nonce), []byte(nonce)) != 1 {
+		return nil, errors.New("invalid invite link")
+	}
+
+	// A link grants its role on behalf of whoever created it, so it stops
+	// working once they can no longer grant that role themselves.
+	if err := s.checkGrant(ctx, link.TeamID, link.CreatedByID, link.Role); err != nil {
+		return nil, errors.New("this invite link is no longer valid")
+	}
+
+	var membership *models.TeamMembership
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := s.quotaService.CheckLimit(ctx, tx, link.TeamID, QuotaMembers, 1); err != nil {
+			return err
+		}
+
+		exists, err := tx.NewSelect().
+			Model((*models.TeamMembership)(nil)).
+			Where("team_id = ?", link.TeamID).
+			Where("user_id = ?", userID).
+			Exists(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			return errors.New("you are already a member of this team")
+		}
+
+		now := time.Now()
+		result, err := tx.NewUpdate().
+			Model((*models.TeamInviteLink)(nil)).
+			Set("use_count = use_count + 1").
+			Set("updated_at = ?", now).
+			Where("id = ?", link.ID).
+			Where("revoked_at IS NULL").
+			Where("expires_at IS NULL OR expires_at > ?", now).
+			Where("max_uses = 0 OR use_count < max_uses").
+			Exec(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			return errors.New("this invite link has expired, been revoked or reached its usage limit")
+		}
+
+		membership = &models.TeamMembership{
+			TeamID: link.TeamID,
+			UserID: userID,
+			Role:   link.Role,
+		}
+
+		_, err = tx.NewInsert().Model(membership).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		redemption := &models.TeamInviteLinkRedemption{
+			LinkID: link.ID,
+			TeamID: link.TeamID,
+			UserID: userID,
+			IP:     ip,
+		}
+
+		_, err = tx.NewInsert().Model(redemption).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, tx, link.TeamID, userID, models.AuditMemberJoined, "member", userID, map[string]models.AuditChange{
+			"role":           {To: membership.Role},
+			"invite_link_id": {To: link.ID},
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+func (s *TeamService) inviteLinkToken(link *models.TeamInviteLink) string {
+	return utils.SignToken(s.secret, inviteLinkTokenScope, fmt.Sprintf("%d.%s", link.ID, link.Nonce))
+}
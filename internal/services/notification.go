@@ -3,46 +3,69 @@ package services
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/uptrace/bun"
 
+	"github.com/open-move/intercord/internal/authz"
 	"github.com/open-move/intercord/internal/models"
 )
 
 type NotificationService struct {
-	db          *bun.DB
-	teamService *TeamService
+	db           *bun.DB
+	quotaService *QuotaService
 }
 
-func NewNotificationService(db *bun.DB, teamService *TeamService) *NotificationService {
+func NewNotificationService(db *bun.DB, quotaService *QuotaService) *NotificationService {
 	return &NotificationService{
-		db:          db,
-		teamService: teamService,
+		db:           db,
+		quotaService: quotaService,
 	}
 }
 
-func (s *NotificationService) GetByID(ctx context.Context, id int64, userID int64) (*models.Notification, error) {
+func (s *NotificationService) GetByID(ctx context.Context, id int64) (*models.Notification, error) {
 	notification := new(models.Notification)
 	err := s.db.NewSelect().Model(notification).Where("id = ?", id).Scan(ctx)
 	if err != nil {
 		return nil, errors.New("notification not found")
 	}
 
-	subscription := new(models.Subscription)
-	err = s.db.NewSelect().Model(subscription).Where("id = ?", notification.SubscriptionID).Scan(ctx)
-	if err != nil {
-		return nil, errors.New("subscription not found")
-	}
+	return notification, nil
+}
 
-	if subscription.UserID != userID {
-		if subscription.TeamID == nil {
-			return nil, errors.New("you don't have permission to view this notification")
+func (s *NotificationService) Redrive(ctx context.Context, id int64) (*models.Notification, error) {
+	notification := new(models.Notification)
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		err := tx.NewUpdate().
+			Model(notification).
+			Set("status = ?", models.NotificationStatusPending).
+			Set("error_message = ''").
+			Set("updated_at = ?", time.Now()).
+			Where("id = ?", id).
+			Where("status = ?", models.NotificationStatusFailed).
+			Returning("*").
+			Scan(ctx)
+
+		if err != nil {
+			return errors.New("only failed notifications can be redriven")
 		}
 
-		_, err = s.teamService.GetMembership(ctx, *subscription.TeamID, userID)
+		// A redrive is another delivery, so it counts against the team's quota.
+		subscription := new(models.Subscription)
+		err = tx.NewSelect().Model(subscription).Where("id = ?", notification.SubscriptionID).Scan(ctx)
 		if err != nil {
-			return nil, errors.New("you don't have permission to view this notification")
+			return err
 		}
+
+		if subscription.TeamID == nil {
+			return nil
+		}
+
+		return s.quotaService.MeterNotification(ctx, tx, *subscription.TeamID)
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
 	return notification, nil
@@ -63,17 +86,15 @@ func (s *NotificationService) GetUserNotifications(ctx context.Context, userID i
 	var teamMemberships []models.TeamMembership
 	err = s.db.NewSelect().
 		Model(&teamMemberships).
-		Where("user_id = ?", userID).
+		Relation("CustomRole").
+		Where("tm.user_id = ?", userID).
 		Scan(ctx)
 
 	if err != nil {
 		return nil, 0, err
 	}
 
-	var teamIDs []int64
-	for _, membership := range teamMemberships {
-		teamIDs = append(teamIDs, membership.TeamID)
-	}
+	teamIDs := authz.TeamsAllowing(teamMemberships, authz.NotificationRead)
 
 	if len(subscriptions) == 0 && len(teamIDs) == 0 {
 		return []models.Notification{}, 0, nil
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/move"
+	"github.com/open-move/intercord/internal/sui"
+)
+
+// ObjectChangeTypes are the object changes an object subscription can watch.
+var ObjectChangeTypes = []string{
+	sui.ObjectCreated,
+	sui.ObjectMutated,
+	sui.ObjectTransferred,
+	sui.ObjectWrapped,
+	sui.ObjectDeleted,
+}
+
+// ObjectActivity is the notification payload of an object subscription: one
+// change to a watched object.
+type ObjectActivity struct {
+	Digest          string     `json:"digest"`
+	Sender          string     `json:"sender"`
+	Status          string     `json:"status"`
+	Checkpoint      string     `json:"checkpoint,omitempty"`
+	Timestamp       *time.Time `json:"timestamp,omitempty"`
+	Change          string     `json:"change"`
+	ObjectID        string     `json:"object_id"`
+	ObjectType      string     `json:"object_type,omitempty"`
+	Version         string     `json:"version"`
+	PreviousVersion string     `json:"previous_version,omitempty"`
+	Owner           *sui.Owner `json:"owner"`
+	PreviousOwner   *sui.Owner `json:"previous_owner"`
+}
+
+// addObjectStreams follows each watched object through the transactions that
+// affected it. The node can't filter by object type, so type watches follow the
+// transactions calling into the package defining the type instead, and miss
+// objects moved without calling it, such as by a TransferObjects command.
+func (s *IngestionService) addObjectStreams(streams transactionStreams, subscription *models.Subscription) {
+	if subscription.ObjectType != "" {
+		pattern, err := move.ParseEventPattern(subscription.ObjectType)
+		if err != nil {
+			log.Printf("Skipping subscription %d with invalid object type %q: %v", subscription.ID, subscription.ObjectType, err)
+			return
+		}
+
+		if isFrameworkPackage(pattern.Address) {
+			log.Printf("Skipping subscription %d watching framework type %s", subscription.ID, subscription.ObjectType)
+			return
+		}
+
+		streams.add("object-type:"+pattern.Address, sui.MoveFunction(pattern.Address), subscription, s.objectPayloads(matchesObjectType))
+		return
+	}
+
+	for _, objectID := range subscription.ObjectIDs {
+		streams.add("object:"+objectID, sui.AffectedObject(objectID), subscription, s.objectPayloads(func(change sui.ObjectChange, _ *models.Subscription) bool {
+			return sameAddress(change.ObjectID, objectID)
+		}))
+	}
+}
+
+// isFrameworkPackage reports whether a package is the Move standard library,
+// the Sui framework or the Sui system package. Nearly every transaction calls
+// one of them, so a type watch following those calls could never keep up.
+func isFrameworkPackage(address string) bool {
+	switch strings.TrimLeft(strings.TrimPrefix(address, "0x"), "0") {
+	case "1", "2", "3":
+		return true
+	}
+	return false
+}
+
+func matchesObjectType(change sui.ObjectChange, subscription *models.Subscription) bool {
+	pattern, err := move.ParseEventPattern(subscription.ObjectType)
+	if err != nil {
+		return false
+	}
+
+	tag, err := move.ParseStructTag(change.ObjectType)
+	return err == nil && pattern.Matches(tag)
+}
+
+// objectPayloads describes each change of a transaction that a subscription
+// watches, looking up who owned the object before.
+func (s *IngestionService) objectPayloads(match func(change sui.ObjectChange, subscription *models.Subscription) bool) streamPayloads {
+	return func(ctx context.Context, block sui.TransactionBlock, subscription *models.Subscription) ([]interface{}, error) {
+		watches := func(changeType string) bool {
+			return len(subscription.ObjectChangeTypes) == 0 || slices.Contains(subscription.ObjectChangeTypes, changeType)
+		}
+
+		var payloads []interface{}
+		for _, change := range block.ObjectChanges {
+			if !slices.Contains(ObjectChangeTypes, change.Type) || !match(change, subscription) {
+				continue
+			}
+
+			// A mutation turns out to be a transfer once the previous
+			// owner is known.
+			if change.Type == sui.ObjectMutated {
+				if !watches(sui.ObjectMutated) && !watches(sui.ObjectTransferred) {
+					continue
+				}
+			} else if !watches(change.Type) {
+				continue
+			}
+
+			activity, err := s.objectActivity(ctx, block, change)
+			if err != nil {
+				return nil, err
+			}
+
+			if watches(activity.Change) {
+				payloads = append(payloads, activity)
+			}
+		}
+
+		return payloads, nil
+	}
+}
+
+func (s *IngestionService) objectActivity(ctx context.Context, block sui.TransactionBlock, change sui.ObjectChange) (*ObjectActivity, error) {
+	sender, err := move.NormalizeAddress(block.Sender())
+	if err != nil {
+		sender = block.Sender()
+	}
+
+	activity := &ObjectActivity{
+		Digest:          block.Digest,
+		Sender:          sender,
+		Checkpoint:      block.Checkpoint,
+		Change:          change.Type,
+		ObjectID:        change.ObjectID,
+		ObjectType:      change.ObjectType,
+		Version:         change.Version,
+		PreviousVersion: change.PreviousVersion,
+		Owner:           change.Owner,
+	}
+
+	if change.Type == sui.ObjectTransferred {
+		activity.Owner = change.Recipient
+	}
+
+	if executed := block.Timestamp(); !executed.IsZero() {
+		activity.Timestamp = &executed
+	}
+
+	if block.Effects != nil {
+		activity.Status = block.Effects.Status.Status
+	}
+
+	if activity.PreviousVersion == "" {
+		activity.PreviousVersion = previousVersion(block, change)
+	}
+
+	if change.Type != sui.ObjectCreated && activity.PreviousVersion != "" {
+		// Nodes prune old versions; the previous owner is then left out
+		// rather than holding up the stream.
+		owner, err := s.client.GetPastObjectOwner(ctx, change.ObjectID, activity.PreviousVersion)
+		if isRPCError(err) {
+			log.Printf("Failed to look up the owner of %s at version %s: %v", change.ObjectID, activity.PreviousVersion, err)
+		} else if err != nil {
+			return nil, err
+		}
+		activity.PreviousOwner = owner
+	}
+
+	// Nodes report transfers as mutations, so a mutation that changed the
+	// owner is reported as a transfer.
+	if change.Type == sui.ObjectMutated && activity.PreviousOwner != nil && activity.Owner != nil && !sameOwner(activity.PreviousOwner, activity.Owner) {
+		activity.Change = sui.ObjectTransferred
+	}
+
+	return activity, nil
+}
+
+// previousVersion returns the version an object had before a transaction
+// changed it, or "" for objects it created.
+func previousVersion(block sui.TransactionBlock, change sui.ObjectChange) string {
+	if change.PreviousVersion != "" || block.Effects == nil {
+		return change.PreviousVersion
+	}
+
+	for _, modified := range block.Effects.ModifiedAtVersions {
+		if sameAddress(modified.ObjectID, change.ObjectID) {
+			return modified.SequenceNumber
+		}
+	}
+	return ""
+}
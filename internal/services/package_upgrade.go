@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/move"
+	"github.com/open-move/intercord/internal/sui"
+)
+
+// Package changes reported by package subscriptions.
+const (
+	PackageUpgraded       = "upgraded"
+	PackagePolicyChanged  = "policy_changed"
+	PackageCapTransferred = "cap_transferred"
+	PackageCapWrapped     = "cap_wrapped"
+	PackageMadeImmutable  = "made_immutable"
+)
+
+// PackageService finds the UpgradeCap controlling a package.
+type PackageService struct {
+	client *sui.Client
+}
+
+func NewPackageService(client *sui.Client) *PackageService {
+	return &PackageService{client: client}
+}
+
+// UpgradeCap returns the ID of the UpgradeCap that published a version of a
+// package. Every version is published by a transaction creating or using the
+// cap, so it is found among that transaction's object changes.
+func (s *PackageService) UpgradeCap(ctx context.Context, packageID string) (string, error) {
+	pkg, err := s.client.GetObject(ctx, packageID, map[string]bool{"showType": true, "showPreviousTransaction": true})
+	if err != nil {
+		return "", err
+	}
+	if pkg == nil || pkg.Type != "package" {
+		return "", fmt.Errorf("package %s was not found on chain", packageID)
+	}
+
+	published, err := s.client.GetTransactionBlock(ctx, pkg.PreviousTransaction)
+	if err != nil {
+		return "", err
+	}
+
+	for _, change := range published.ObjectChanges {
+		if change.Type != sui.ObjectCreated && change.Type != sui.ObjectMutated {
+			continue
+		}
+		if sameStructType(change.ObjectType, upgradeCapType()) {
+			capID, err := move.NormalizeAddress(change.ObjectID)
+			if err != nil {
+				return "", err
+			}
+
+			current, err := s.client.GetObject(ctx, capID, map[string]bool{"showType": true})
+			if err != nil {
+				return "", err
+			}
+			if current == nil {
+				return "", fmt.Errorf("package %s is immutable", packageID)
+			}
+			return capID, nil
+		}
+	}
+
+	return "", fmt.Errorf("package %s has no upgrade cap", packageID)
+}
+
+func upgradeCapType() string {
+	tag, _ := move.ParseStructTag(sui.UpgradeCapType)
+	return tag.String()
+}
+
+// ModuleChanges lists the modules an upgrade added, removed or recompiled.
+type ModuleChanges struct {
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+// PackageUpgrade is the notification payload of a package subscription: one
+// transaction that upgraded the package or changed its UpgradeCap.
+type PackageUpgrade struct {
+	Changes           []string       `json:"changes"`
+	PackageID         string         `json:"package_id"`
+	UpgradeCapID      string         `json:"upgrade_cap_id"`
+	PreviousPackageID string         `json:"previous_package_id,omitempty"`
+	NewPackageID      string         `json:"new_package_id,omitempty"`
+	Version           string         `json:"version,omitempty"`
+	Modules           *ModuleChanges `json:"modules,omitempty"`
+	PreviousPolicy    string         `json:"previous_policy,omitempty"`
+	Policy            string         `json:"policy,omitempty"`
+	PreviousOwner     *sui.Owner     `json:"previous_owner,omitempty"`
+	Owner             *sui.Owner     `json:"owner,omitempty"`
+	Digest            string         `json:"digest"`
+	Sender            string         `json:"sender"`
+	Timestamp         *time.Time     `json:"timestamp,omitempty"`
+}
+
+// addPackageStreams follows the transactions that affected a package's
+// UpgradeCap, which every upgrade, policy change and transfer does, as does
+// making the package immutable or wrapping the cap.
+func (s *IngestionService) addPackageStreams(streams transactionStreams, subscription *models.Subscription) {
+	capID := subscription.UpgradeCapID
+	streams.add("package:"+capID, sui.AffectedObject(capID), subscription, s.packagePayloads)
+}
+
+func (s *IngestionService) packagePayloads(ctx context.Context, block sui.TransactionBlock, subscription *models.Subscription) ([]interface{}, error) {
+	var capChange, published *sui.ObjectChange
+	for i := range block.ObjectChanges {
+		change := &block.ObjectChanges[i]
+		switch {
+		case change.Type == sui.ObjectPublished:
+			published = change
+		case sameAddress(change.ObjectID, subscription.UpgradeCapID):
+			capChange = change
+		}
+	}
+
+	if capChange == nil {
+		return nil, nil
+	}
+
+	sender, err := move.NormalizeAddress(block.Sender())
+	if err != nil {
+		sender = block.Sender()
+	}
+
+	payload := &PackageUpgrade{
+		PackageID:    subscription.PackageID,
+		UpgradeCapID: subscription.UpgradeCapID,
+		Digest:       block.Digest,
+		Sender:       sender,
+	}
+
+	if executed := block.Timestamp(); !executed.IsZero() {
+		payload.Timestamp = &executed
+	}
+
+	before, err := s.upgradeCapAt(ctx, subscription.UpgradeCapID, previousVersion(block, *capChange))
+	if err != nil {
+		return nil, err
+	}
+
+	var after *sui.ObjectData
+	switch capChange.Type {
+	case sui.ObjectDeleted:
+		payload.Changes = append(payload.Changes, PackageMadeImmutable)
+	case sui.ObjectWrapped:
+		payload.Changes = append(payload.Changes, PackageCapWrapped)
+	default:
+		after, err = s.upgradeCapAt(ctx, subscription.UpgradeCapID, capChange.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var beforeCap, afterCap *sui.UpgradeCap
+	if before != nil {
+		payload.PreviousOwner = before.Owner
+		if beforeCap, err = before.UpgradeCap(); err != nil {
+			return nil, err
+		}
+		payload.PreviousPackageID = beforeCap.Package
+		payload.PreviousPolicy = sui.UpgradePolicies[beforeCap.Policy.String()]
+	}
+
+	if after != nil {
+		payload.Owner = after.Owner
+		if afterCap, err = after.UpgradeCap(); err != nil {
+			return nil, err
+		}
+		payload.Version = afterCap.Version.String()
+		payload.Policy = sui.UpgradePolicies[afterCap.Policy.String()]
+	}
+
+	if published != nil {
+		payload.Changes = append(payload.Changes, PackageUpgraded)
+		payload.NewPackageID = published.PackageID
+
+		if payload.PreviousPackageID != "" {
+			payload.Modules, err = s.moduleChanges(ctx, payload.PreviousPackageID, published.PackageID)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if beforeCap != nil && afterCap != nil && beforeCap.Policy != afterCap.Policy {
+		payload.Changes = append(payload.Changes, PackagePolicyChanged)
+	}
+
+	if before != nil && after != nil && !sameOwner(before.Owner, after.Owner) {
+		payload.Changes = append(payload.Changes, PackageCapTransferred)
+	}
+
+	// Authorizing an upgrade that was never committed touches the cap
+	// without changing it.
+	if len(payload.Changes) == 0 {
+		return nil, nil
+	}
+
+	return []interface{}{payload}, nil
+}
+
+// upgradeCapAt returns the UpgradeCap at a version, or nil when the node has
+// pruned it.
+func (s *IngestionService) upgradeCapAt(ctx context.Context, capID, version string) (*sui.ObjectData, error) {
+	if version == "" {
+		return nil, nil
+	}
+
+	object, err := s.client.GetPastObject(ctx, capID, version, map[string]bool{"showContent": true, "showOwner": true})
+	if isRPCError(err) {
+		log.Printf("Failed to look up upgrade cap %s at version %s: %v", capID, version, err)
+		return nil, nil
+	}
+	return object, err
+}
+
+// moduleChanges compares the bytecode of two versions of a package. Modules
+// that weren't recompiled with changes keep the same bytecode.
+func (s *IngestionService) moduleChanges(ctx context.Context, previousID, newID string) (*ModuleChanges, error) {
+	previous, err := s.client.GetPackageModules(ctx, previousID)
+	if err == nil {
+		var current map[string][]byte
+		current, err = s.client.GetPackageModules(ctx, newID)
+		if err == nil {
+			return diffModules(previous, current), nil
+		}
+	}
+
+	if isRPCError(err) {
+		log.Printf("Failed to compare packages %s and %s: %v", previousID, newID, err)
+		return nil, nil
+	}
+	return nil, err
+}
+
+func diffModules(previous, current map[string][]byte) *ModuleChanges {
+	changes := &ModuleChanges{Added: []string{}, Removed: []string{}, Modified: []string{}}
+	for name, bytecode := range current {
+		old, ok := previous[name]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, name)
+		case string(old) != string(bytecode):
+			changes.Modified = append(changes.Modified, name)
+		}
+	}
+
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			changes.Removed = append(changes.Removed, name)
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Modified)
+
+	return changes
+}
+
+func sameOwner(a, b *sui.Owner) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Kind != b.Kind {
+		return false
+	}
+
+	normalized, err := move.NormalizeAddress(b.Address)
+	if err != nil {
+		return a.Address == b.Address
+	}
+	return sameAddress(a.Address, normalized)
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/open-move/intercord/internal/models"
+)
+
+// PlanLimits caps what a team on a plan can have. Zero means unlimited.
+type PlanLimits struct {
+	Subscriptions        int `json:"subscriptions"`
+	Channels             int `json:"channels"`
+	Members              int `json:"members"`
+	MonthlyNotifications int `json:"monthly_notifications"`
+	RetentionDays        int `json:"retention_days"`
+}
+
+var planLimits = map[models.TeamPlan]PlanLimits{
+	models.TeamPlanFree: {
+		Subscriptions:        10,
+		Channels:             5,
+		Members:              5,
+		MonthlyNotifications: 10000,
+		RetentionDays:        7,
+	},
+	models.TeamPlanPro: {
+		Subscriptions:        200,
+		Channels:             50,
+		Members:              50,
+		MonthlyNotifications: 1000000,
+		RetentionDays:        90,
+	},
+	models.TeamPlanEnterprise: {
+		RetentionDays: 365,
+	},
+}
+
+func LimitsFor(plan models.TeamPlan) PlanLimits {
+	if limits, ok := planLimits[plan]; ok {
+		return limits
+	}
+	return planLimits[models.TeamPlanFree]
+}
+
+type QuotaResource string
+
+const (
+	QuotaSubscriptions QuotaResource = "subscriptions"
+	QuotaChannels      QuotaResource = "channels"
+	QuotaMembers       QuotaResource = "members"
+)
+
+// LimitError reports that an action would take a team past its plan. The API
+// answers it with 402 Payment Required.
+type LimitError struct {
+	Plan     models.TeamPlan
+	Resource QuotaResource
+	Limit    int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("the %s plan allows at most %d %s; upgrade the team's plan to add more", e.Plan, e.Limit, e.Resource)
+}
+
+// ErrNotificationQuotaExceeded is returned once a team has used its monthly
+// notifications. The API answers it with 429 Too Many Requests.
+var ErrNotificationQuotaExceeded = errors.New("the team has used its monthly notification quota")
+
+type QuotaService struct {
+	db *bun.DB
+}
+
+func NewQuotaService(db *bun.DB) *QuotaService {
+	return &QuotaService{
+		db: db,
+	}
+}
+
+type UsageCounts struct {
+	Subscriptions int   `json:"subscriptions"`
+	Channels      int   `json:"channels"`
+	Members       int   `json:"members"`
+	Notifications int64 `json:"notifications"`
+}
+
+type UsageReport struct {
+	Plan   models.TeamPlan `json:"plan"`
+	Limits PlanLimits      `json:"limits"`
+	Period time.Time       `json:"period"`
+	Usage  UsageCounts     `json:"usage"`
+}
+
+func (s *QuotaService) GetUsage(ctx context.Context, teamID int64) (*UsageReport, error) {
+	team := new(models.Team)
+	err := s.db.NewSelect().Model(team).Where("id = ?", teamID).Scan(ctx)
+	if err != nil {
+		return nil, errors.New("team not found")
+	}
+
+	report := &UsageReport{
+		Plan:   team.Plan,
+		Limits: LimitsFor(team.Plan),
+		Period: usagePeriod(time.Now()),
+	}
+
+	for resource, target := range map[QuotaResource]*int{
+		QuotaSubscriptions: &report.Usage.Subscriptions,
+		QuotaChannels:      &report.Usage.Channels,
+		QuotaMembers:       &report.Usage.Members,
+	} {
+		if *target, err = countResource(ctx, s.db, teamID, resource); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.db.NewSelect().
+		Model((*models.TeamUsage)(nil)).
+		Column("notifications").
+		Where("team_id = ?", teamID).
+		Where("period = ?", report.Period).
+		Scan(ctx, &report.Usage.Notifications)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// CheckLimit verifies that adding n of a resource keeps a team within its
+// plan. It locks the team row, so call it inside the transaction that adds the
+// resources to keep concurrent requests from both taking the last slot.
+func (s *QuotaService) CheckLimit(ctx context.Context, tx bun.Tx, teamID int64, resource QuotaResource, n int) error {
+	team := new(models.Team)
+	err := tx.NewSelect().Model(team).Where("id = ?", teamID).For("UPDATE").Scan(ctx)
+	if err != nil {
+		return errors.New("team not found")
+	}
+
+	limits := LimitsFor(team.Plan)
+	limit := map[QuotaResource]int{
+		QuotaSubscriptions: limits.Subscriptions,
+		QuotaChannels:      limits.Channels,
+		QuotaMembers:       limits.Members,
+	}[resource]
+
+	if limit == 0 {
+		return nil
+	}
+
+	current, err := countResource(ctx, tx, teamID, resource)
+	if err != nil {
+		return err
+	}
+
+	if current+n > limit {
+		return &LimitError{Plan: team.Plan, Resource: resource, Limit: limit}
+	}
+
+	return nil
+}
+
+// MeterNotification counts one notification against the team's monthly
+// quota, refusing it once the quota is used up.
+func (s *QuotaService) MeterNotification(ctx context.Context, db bun.IDB, teamID int64) error {
+	team := new(models.Team)
+	err := db.NewSelect().Model(team).Where("id = ?", teamID).Scan(ctx)
+	if err != nil {
+		return errors.New("team not found")
+	}
+
+	usage := &models.TeamUsage{
+		TeamID:        teamID,
+		Period:        usagePeriod(time.Now()),
+		Notifications: 1,
+		UpdatedAt:     time.Now(),
+	}
+
+	query := db.NewInsert().
+		Model(usage).
+		On("CONFLICT (team_id, period) DO UPDATE").
+		Set("notifications = tu.notifications + 1").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("NULL")
+
+	if limit := LimitsFor(team.Plan).MonthlyNotifications; limit > 0 {
+		query = query.Where("tu.notifications < ?", limit)
+	}
+
+	result, err := query.Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return ErrNotificationQuotaExceeded
+	}
+
+	return nil
+}
+
+// PurgeExpiredNotifications deletes team notifications older than their
+// plan's retention.
+func (s *QuotaService) PurgeExpiredNotifications(ctx context.Context) error {
+	for plan, limits := range planLimits {
+		if limits.RetentionDays == 0 {
+			continue
+		}
+
+		teamIDs := s.db.NewSelect().
+			Model((*models.Team)(nil)).
+			Column("id").
+			Where("plan = ?", plan).
+			WhereAllWithDeleted()
+
+		subscriptionIDs := s.db.NewSelect().
+			Model((*models.Subscription)(nil)).
+			Column("id").
+			Where("team_id IN (?)", teamIDs).
+			WhereAllWithDeleted()
+
+		_, err := s.db.NewDelete().
+			Model((*models.Notification)(nil)).
+			Where("subscription_id IN (?)", subscriptionIDs).
+			Where("created_at < ?", time.Now().AddDate(0, 0, -limits.RetentionDays)).
+			Exec(ctx)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func countResource(ctx context.Context, db bun.IDB, teamID int64, resource QuotaResource) (int, error) {
+	var model interface{}
+	switch resource {
+	case QuotaSubscriptions:
+		model = (*models.Subscription)(nil)
+	case QuotaChannels:
+		model = (*models.Channel)(nil)
+	case QuotaMembers:
+		model = (*models.TeamMembership)(nil)
+	default:
+		return 0, fmt.Errorf("unknown quota resource %q", resource)
+	}
+
+	return db.NewSelect().Model(model).Where("team_id = ?", teamID).Count(ctx)
+}
+
+func usagePeriod(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/open-move/intercord/internal/config"
+	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/utils"
+)
+
+const sessionLastSeenResolution = time.Minute
+
+type SessionService struct {
+	db        *bun.DB
+	jwtConfig *config.JWTConfig
+}
+
+func NewSessionService(db *bun.DB, jwtConfig *config.JWTConfig) *SessionService {
+	return &SessionService{
+		db:        db,
+		jwtConfig: jwtConfig,
+	}
+}
+
+func (s *SessionService) Start(ctx context.Context, user *models.User, ip, userAgent string) (*AuthResponse, error) {
+	session := &models.Session{
+		UserID:     user.ID,
+		UserAgent:  userAgent,
+		IP:         ip,
+		LastSeenAt: time.Now(),
+		ExpiresAt:  time.Now().Add(s.jwtConfig.RefreshTokenTTL),
+	}
+
+	_, err := s.db.NewInsert().Model(session).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := utils.GenerateAccessToken(user.ID, session.ID, s.jwtConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateRefreshToken(user.ID, session.ID, s.jwtConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResponse{
+		User:         *user,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func (s *SessionService) ValidateToken(ctx context.Context, claims *utils.Claims) error {
+	session := new(models.Session)
+	err := s.db.NewSelect().
+		Model(session).
+		Where("id = ?", claims.SessionID).
+		Where("user_id = ?", claims.UserID).
+		Scan(ctx)
+
+	if err != nil {
+		return errors.New("session not found")
+	}
+
+	now := time.Now()
+	if !session.RevokedAt.IsZero() || session.ExpiresAt.Before(now) {
+		return errors.New("session has been revoked")
+	}
+
+	if now.Sub(session.LastSeenAt) < sessionLastSeenResolution {
+		return nil
+	}
+
+	_, err = s.db.NewUpdate().
+		Model((*models.Session)(nil)).
+		Set("last_seen_at = ?", now).
+		Where("id = ?", session.ID).
+		Exec(ctx)
+
+	return err
+}
+
+func (s *SessionService) List(ctx context.Context, userID, currentSessionID int64) ([]models.Session, error) {
+	var sessions []models.Session
+	err := s.db.NewSelect().
+		Model(&sessions).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Where("expires_at > ?", time.Now()).
+		Order("last_seen_at DESC").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+func (s *SessionService) Revoke(ctx context.Context, userID, sessionID int64) error {
+	result, err := s.db.NewUpdate().
+		Model((*models.Session)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("id = ?", sessionID).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return errors.New("session not found")
+	}
+
+	return nil
+}
+
+func (s *SessionService) RevokeAll(ctx context.Context, db bun.IDB, userID, exceptSessionID int64) error {
+	_, err := db.NewUpdate().
+		Model((*models.Session)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("id != ?", exceptSessionID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+
+	return err
+}
+
+func (s *SessionService) PurgeExpired(ctx context.Context) error {
+	_, err := s.db.NewDelete().
+		Model((*models.Session)(nil)).
+		WhereOr("expires_at < ?", time.Now()).
+		WhereOr("revoked_at IS NOT NULL").
+		Exec(ctx)
+
+	return err
+}
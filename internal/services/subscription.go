@@ -3,61 +3,169 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/uptrace/bun"
 
+	"github.com/open-move/intercord/internal/filter"
 	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/move"
+	"github.com/open-move/intercord/internal/sui"
 )
 
 type SubscriptionService struct {
-	db          *bun.DB
-	teamService *TeamService
+	db               *bun.DB
+	auditService     *AuditService
+	quotaService     *QuotaService
+	eventTypeService *EventTypeService
+	coinService      *CoinService
+	packageService   *PackageService
+	systemService    *SystemService
 }
 
-func NewSubscriptionService(db *bun.DB, teamService *TeamService) *SubscriptionService {
+func NewSubscriptionService(db *bun.DB, auditService *AuditService, quotaService *QuotaService, eventTypeService *EventTypeService, coinService *CoinService, packageService *PackageService, systemService *SystemService) *SubscriptionService {
 	return &SubscriptionService{
-		db:          db,
-		teamService: teamService,
+		db:               db,
+		auditService:     auditService,
+		quotaService:     quotaService,
+		eventTypeService: eventTypeService,
+		coinService:      coinService,
+		packageService:   packageService,
+		systemService:    systemService,
 	}
 }
 
 type CreateSubscriptionInput struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
-	EventType   string `json:"event_type" binding:"required"`
+	Kind        string `json:"kind" binding:"omitempty,oneof=event address object balance package system"`
+	EventType   string `json:"event_type"`
+	Filter      string `json:"filter" binding:"max=2000"`
 	TeamID      *int64 `json:"team_id"`
+
+	Senders          []string `json:"senders"`
+	EmitterPackage   string   `json:"emitter_package"`
+	EmitterModule    string   `json:"emitter_module"`
+	TransactionKinds []string `json:"transaction_kinds"`
+
+	Addresses        []string `json:"addresses"`
+	AddressDirection string   `json:"address_direction" binding:"omitempty,oneof=from to any"`
+
+	ObjectIDs         []string `json:"object_ids"`
+	ObjectType        string   `json:"object_type"`
+	ObjectChangeTypes []string `json:"object_change_types"`
+
+	BalanceAddress     string `json:"balance_address"`
+	CoinType           string `json:"coin_type"`
+	Threshold          string `json:"threshold"`
+	ThresholdDirection string `json:"threshold_direction" binding:"omitempty,oneof=below above"`
+	Hysteresis         string `json:"hysteresis"`
+
+	PackageID string `json:"package_id"`
+
+	SystemEvents []string `json:"system_events"`
+	Validators   []string `json:"validators"`
 }
 
 type UpdateSubscriptionInput struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	EventType   string `json:"event_type"`
-	IsActive    *bool  `json:"is_active"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	EventType   string  `json:"event_type"`
+	Filter      *string `json:"filter" binding:"omitempty,max=2000"`
+	IsActive    *bool   `json:"is_active"`
+
+	Senders          *[]string `json:"senders"`
+	EmitterPackage   *string   `json:"emitter_package"`
+	EmitterModule    *string   `json:"emitter_module"`
+	TransactionKinds *[]string `json:"transaction_kinds"`
+
+	Addresses        *[]string `json:"addresses"`
+	AddressDirection *string   `json:"address_direction" binding:"omitempty,oneof=from to any"`
+
+	ObjectIDs         *[]string `json:"object_ids"`
+	ObjectType        *string   `json:"object_type"`
+	ObjectChangeTypes *[]string `json:"object_change_types"`
+
+	BalanceAddress     *string `json:"balance_address"`
+	CoinType           *string `json:"coin_type"`
+	Threshold          *string `json:"threshold"`
+	ThresholdDirection *string `json:"threshold_direction" binding:"omitempty,oneof=below above"`
+	Hysteresis         *string `json:"hysteresis"`
+
+	PackageID *string `json:"package_id"`
+
+	SystemEvents *[]string `json:"system_events"`
+	Validators   *[]string `json:"validators"`
 }
 
 func (s *SubscriptionService) Create(ctx context.Context, input CreateSubscriptionInput, userID int64) (*models.Subscription, error) {
-
-	if input.TeamID != nil && *input.TeamID != 0 {
-		membership, err := s.teamService.GetMembership(ctx, *input.TeamID, userID)
-		if err != nil {
-			return nil, errors.New("you are not a member of this team")
-		}
-
-		if membership.Role != models.TeamRoleOwner && membership.Role != models.TeamRoleAdmin {
-			return nil, errors.New("you don't have permission to create subscriptions for this team")
-		}
+	kind := models.SubscriptionKind(input.Kind)
+	if kind == "" {
+		kind = models.SubscriptionKindEvent
 	}
 
 	subscription := &models.Subscription{
 		Name:        input.Name,
 		Description: input.Description,
+		Kind:        kind,
 		EventType:   input.EventType,
+		Filter:      input.Filter,
 		TeamID:      input.TeamID,
 		UserID:      userID,
 		IsActive:    true,
+
+		Senders:          input.Senders,
+		EmitterPackage:   input.EmitterPackage,
+		EmitterModule:    input.EmitterModule,
+		TransactionKinds: input.TransactionKinds,
+
+		Addresses:        input.Addresses,
+		AddressDirection: models.AddressDirection(input.AddressDirection),
+
+		ObjectIDs:         input.ObjectIDs,
+		ObjectType:        input.ObjectType,
+		ObjectChangeTypes: input.ObjectChangeTypes,
+
+		BalanceAddress:     input.BalanceAddress,
+		CoinType:           input.CoinType,
+		Threshold:          input.Threshold,
+		ThresholdDirection: models.ThresholdDirection(input.ThresholdDirection),
+		Hysteresis:         input.Hysteresis,
+
+		PackageID: input.PackageID,
+
+		SystemEvents: input.SystemEvents,
+		Validators:   input.Validators,
 	}
 
-	_, err := s.db.NewInsert().Model(subscription).Exec(ctx)
+	if err := s.prepare(ctx, subscription, true); err != nil {
+		return nil, err
+	}
+
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if subscription.TeamID != nil {
+			if err := s.quotaService.CheckLimit(ctx, tx, *subscription.TeamID, QuotaSubscriptions, 1); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.NewInsert().Model(subscription).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return s.auditService.Record(ctx, tx, AuditEntry{
+			TeamID:       subscription.TeamID,
+			ActorID:      userID,
+			Action:       models.AuditSubscriptionCreated,
+			ResourceType: "subscription",
+			ResourceID:   subscription.ID,
+			Changes:      auditDiff(nil, subscriptionAuditFields(subscription)),
+		})
+	})
+
 	if err != nil {
 		return nil, err
 	}
@@ -74,6 +182,15 @@ func (s *SubscriptionService) GetByID(ctx context.Context, id int64) (*models.Su
 	return subscription, nil
 }
 
+func (s *SubscriptionService) GetTrashedByID(ctx context.Context, id int64) (*models.Subscription, error) {
+	subscription := new(models.Subscription)
+	err := s.db.NewSelect().Model(subscription).Where("id = ?", id).WhereDeleted().Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
 func (s *SubscriptionService) GetUserSubscriptions(ctx context.Context, userID int64) ([]models.Subscription, error) {
 	var subscriptions []models.Subscription
 	err := s.db.NewSelect().
@@ -109,20 +226,7 @@ func (s *SubscriptionService) Update(ctx context.Context, id int64, input Update
 		return nil, errors.New("subscription not found")
 	}
 
-	if subscription.UserID != userID {
-		if subscription.TeamID == nil {
-			return nil, errors.New("you don't have permission to update this subscription")
-		}
-
-		membership, err := s.teamService.GetMembership(ctx, *subscription.TeamID, userID)
-		if err != nil {
-			return nil, errors.New("you don't have permission to update this subscription")
-		}
-
-		if membership.Role != models.TeamRoleOwner && membership.Role != models.TeamRoleAdmin {
-			return nil, errors.New("you don't have permission to update this subscription")
-		}
-	}
+	before := subscriptionAuditFields(subscription)
 
 	if input.Name != "" {
 		subscription.Name = input.Name
@@ -132,18 +236,128 @@ func (s *SubscriptionService) Update(ctx context.Context, id int64, input Update
 		subscription.Description = input.Description
 	}
 
-	if input.EventType != "" {
+	eventTypeChanged := false
+	if input.EventType != "" && input.EventType != subscription.EventType {
 		subscription.EventType = input.EventType
+		eventTypeChanged = true
+	}
+
+	if input.Filter != nil {
+		subscription.Filter = *input.Filter
+	}
+
+	if input.Senders != nil {
+		subscription.Senders = *input.Senders
+	}
+
+	if input.EmitterPackage != nil {
+		subscription.EmitterPackage = *input.EmitterPackage
+	}
+
+	if input.EmitterModule != nil {
+		subscription.EmitterModule = *input.EmitterModule
+	}
+
+	if input.TransactionKinds != nil {
+		subscription.TransactionKinds = *input.TransactionKinds
+	}
+
+	if input.Addresses != nil {
+		subscription.Addresses = *input.Addresses
+	}
+
+	if input.AddressDirection != nil {
+		subscription.AddressDirection = models.AddressDirection(*input.AddressDirection)
+	}
+
+	objectTypeChanged := false
+	if input.ObjectIDs != nil {
+		subscription.ObjectIDs = *input.ObjectIDs
+	}
+
+	if input.ObjectType != nil && *input.ObjectType != subscription.ObjectType {
+		subscription.ObjectType = *input.ObjectType
+		objectTypeChanged = true
+	}
+
+	if input.ObjectChangeTypes != nil {
+		subscription.ObjectChangeTypes = *input.ObjectChangeTypes
+	}
+
+	balanceChanged := false
+	if input.BalanceAddress != nil && *input.BalanceAddress != subscription.BalanceAddress {
+		subscription.BalanceAddress = *input.BalanceAddress
+		balanceChanged = true
+	}
+
+	if input.CoinType != nil && *input.CoinType != subscription.CoinType {
+		subscription.CoinType = *input.CoinType
+		balanceChanged = true
+	}
+
+	if input.Threshold != nil && *input.Threshold != subscription.Threshold {
+		subscription.Threshold = *input.Threshold
+		balanceChanged = true
+	}
+
+	if input.ThresholdDirection != nil && models.ThresholdDirection(*input.ThresholdDirection) != subscription.ThresholdDirection {
+		subscription.ThresholdDirection = models.ThresholdDirection(*input.ThresholdDirection)
+		balanceChanged = true
+	}
+
+	if input.Hysteresis != nil && *input.Hysteresis != subscription.Hysteresis {
+		subscription.Hysteresis = *input.Hysteresis
+		balanceChanged = true
+	}
+
+	packageChanged := false
+	if input.PackageID != nil && *input.PackageID != subscription.PackageID {
+		subscription.PackageID = *input.PackageID
+		packageChanged = true
+	}
+
+	validatorsChanged := false
+	if input.SystemEvents != nil {
+		subscription.SystemEvents = *input.SystemEvents
+	}
+
+	if input.Validators != nil && !slices.Equal(*input.Validators, subscription.Validators) {
+		subscription.Validators = *input.Validators
+		validatorsChanged = true
+	}
+
+	if err := s.prepare(ctx, subscription, eventTypeChanged || objectTypeChanged || balanceChanged || packageChanged || validatorsChanged); err != nil {
+		return nil, err
 	}
 
 	if input.IsActive != nil {
 		subscription.IsActive = *input.IsActive
 	}
 
-	_, err = s.db.NewUpdate().Model(subscription).
-		Column("name", "description", "event_type", "is_active", "updated_at").
-		Where("id = ?", id).
-		Exec(ctx)
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().Model(subscription).
+			Column("name", "description", "event_type", "event_fields", "filter", "senders", "emitter_package", "emitter_module", "transaction_kinds", "addresses", "address_direction", "object_ids", "object_type", "object_change_types", "balance_address", "coin_type", "coin_decimals", "threshold", "threshold_direction", "hysteresis", "balance", "triggered", "package_id", "upgrade_cap_id", "system_events", "validators", "is_active", "updated_at").
+			Where("id = ?", id).
+			Exec(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		changes := auditDiff(before, subscriptionAuditFields(subscription))
+		if len(changes) == 0 {
+			return nil
+		}
+
+		return s.auditService.Record(ctx, tx, AuditEntry{
+			TeamID:       subscription.TeamID,
+			ActorID:      userID,
+			Action:       models.AuditSubscriptionUpdated,
+			ResourceType: "subscription",
+			ResourceID:   subscription.ID,
+			Changes:      changes,
+		})
+	})
 
 	if err != nil {
 		return nil, err
@@ -159,38 +373,440 @@ func (s *SubscriptionService) Delete(ctx context.Context, id int64, userID int64
 		return errors.New("subscription not found")
 	}
 
-	if subscription.UserID != userID {
-		if subscription.TeamID == nil {
-			return errors.New("you don't have permission to delete this subscription")
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		deletedAt := trashTime()
+		if err := moveToTrash(ctx, tx, (*models.SubscriptionChannel)(nil), deletedAt, "subscription_id = ?", id); err != nil {
+			return err
+		}
+
+		if err := moveToTrash(ctx, tx, (*models.Subscription)(nil), deletedAt, "id = ?", id); err != nil {
+			return err
+		}
+
+		return s.auditService.Record(ctx, tx, AuditEntry{
+			TeamID:       subscription.TeamID,
+			ActorID:      userID,
+			Action:       models.AuditSubscriptionDeleted,
+			ResourceType: "subscription",
+			ResourceID:   subscription.ID,
+			Changes:      auditDiff(subscriptionAuditFields(subscription), nil),
+		})
+	})
+}
+
+// maxWatchedAddresses, maxWatchedObjects and maxWatchedValidators cap what one
+// subscription watches. Each distinct address or object is polled separately.
+const (
+	maxWatchedAddresses  = 20
+	maxWatchedObjects    = 20
+	maxWatchedValidators = 20
+)
+
+// prepare validates a subscription for its kind and fills in what is derived
+// from its settings. Event and object types are only resolved, and balances
+// only read, on chain when resolve is set, so updates that keep them don't
+// depend on the node.
+func (s *SubscriptionService) prepare(ctx context.Context, subscription *models.Subscription, resolve bool) error {
+	switch subscription.Kind {
+	case models.SubscriptionKindEvent:
+		if subscription.EventType == "" {
+			return errors.New("event_type is required")
+		}
+
+		if err := checkKindFields(subscription); err != nil {
+			return err
+		}
+
+		if resolve {
+			eventType, err := parseEventType(subscription.EventType)
+			if err != nil {
+				return err
+			}
+
+			eventFields, err := s.eventTypeService.Resolve(ctx, eventType)
+			if err != nil {
+				return err
+			}
+
+			subscription.EventType = eventType.String()
+			subscription.EventFields = eventFields
+		}
+
+		if err := checkFilter(subscription.Filter, subscription.EventFields); err != nil {
+			return err
+		}
+
+		return normalizeSourceFilters(subscription)
+
+	case models.SubscriptionKindAddress:
+		if err := checkKindFields(subscription); err != nil {
+			return err
+		}
+
+		return normalizeAddresses(subscription)
+
+	case models.SubscriptionKindObject:
+		if err := checkKindFields(subscription); err != nil {
+			return err
+		}
+
+		return s.normalizeObjects(ctx, subscription, resolve)
+
+	case models.SubscriptionKindBalance:
+		if err := checkKindFields(subscription); err != nil {
+			return err
+		}
+
+		return s.normalizeBalance(ctx, subscription, resolve)
+
+	case models.SubscriptionKindPackage:
+		if err := checkKindFields(subscription); err != nil {
+			return err
+		}
+
+		return s.normalizePackage(ctx, subscription, resolve)
+
+	case models.SubscriptionKindSystem:
+		if err := checkKindFields(subscription); err != nil {
+			return err
+		}
+
+		return s.normalizeSystem(ctx, subscription, resolve)
+
+	default:
+		return fmt.Errorf("unknown subscription kind %q", subscription.Kind)
+	}
+}
+
+// checkKindFields rejects settings that belong to another kind of
+// subscription.
+func checkKindFields(subscription *models.Subscription) error {
+	kind := subscription.Kind
+	if kind != models.SubscriptionKindEvent {
+		if subscription.EventType != "" || subscription.Filter != "" {
+			return fmt.Errorf("%s subscriptions take no event_type or filter", kind)
+		}
+
+		if len(subscription.Senders) > 0 || subscription.EmitterPackage != "" || subscription.EmitterModule != "" || len(subscription.TransactionKinds) > 0 {
+			return fmt.Errorf("%s subscriptions take no sender, emitter or transaction kind filters", kind)
 		}
+	}
 
-		membership, err := s.teamService.GetMembership(ctx, *subscription.TeamID, userID)
+	if kind != models.SubscriptionKindAddress && (len(subscription.Addresses) > 0 || subscription.AddressDirection != "") {
+		return errors.New("addresses only apply to address subscriptions")
+	}
+
+	if kind != models.SubscriptionKindObject && (len(subscription.ObjectIDs) > 0 || subscription.ObjectType != "" || len(subscription.ObjectChangeTypes) > 0) {
+		return errors.New("object_ids, object_type and object_change_types only apply to object subscriptions")
+	}
+
+	if kind != models.SubscriptionKindBalance && (subscription.BalanceAddress != "" || subscription.CoinType != "" || subscription.Threshold != "" || subscription.ThresholdDirection != "" || subscription.Hysteresis != "") {
+		return errors.New("balance_address, coin_type, threshold, threshold_direction and hysteresis only apply to balance subscriptions")
+	}
+
+	if kind != models.SubscriptionKindPackage && subscription.PackageID != "" {
+		return errors.New("package_id only applies to package subscriptions")
+	}
+
+	if kind != models.SubscriptionKindSystem && (len(subscription.SystemEvents) > 0 || len(subscription.Validators) > 0) {
+		return errors.New("system_events and validators only apply to system subscriptions")
+	}
+
+	return nil
+}
+
+// normalizeObjects checks that an object subscription watches either a list of
+// objects or an object type, and puts them in canonical form.
+func (s *SubscriptionService) normalizeObjects(ctx context.Context, subscription *models.Subscription, resolve bool) error {
+	objectIDs := make([]string, 0, len(subscription.ObjectIDs))
+	for _, objectID := range subscription.ObjectIDs {
+		normalized, err := move.NormalizeAddress(strings.TrimSpace(objectID))
+		if err != nil {
+			return fmt.Errorf("invalid object id: %w", err)
+		}
+		if !slices.Contains(objectIDs, normalized) {
+			objectIDs = append(objectIDs, normalized)
+		}
+	}
+
+	switch {
+	case len(objectIDs) > 0 && subscription.ObjectType != "":
+		return errors.New("watch either object_ids or an object_type, not both")
+	case len(objectIDs) == 0 && subscription.ObjectType == "":
+		return errors.New("object_ids or object_type is required")
+	case len(objectIDs) > maxWatchedObjects:
+		return fmt.Errorf("at most %d objects can be watched", maxWatchedObjects)
+	}
+
+	subscription.ObjectIDs = nil
+	if len(objectIDs) > 0 {
+		subscription.ObjectIDs = objectIDs
+	}
+
+	if subscription.ObjectType != "" && resolve {
+		pattern, err := move.ParseEventPattern(subscription.ObjectType)
 		if err != nil {
-			return errors.New("you don't have permission to delete this subscription")
+			return fmt.Errorf("invalid object type: %w", err)
+		}
+
+		if isFrameworkPackage(pattern.Address) {
+			return errors.New("objects of framework types can't be watched by type, watch object_ids instead")
 		}
 
-		if membership.Role != models.TeamRoleOwner && membership.Role != models.TeamRoleAdmin {
-			return errors.New("you don't have permission to delete this subscription")
+		if err := s.eventTypeService.ResolveObjectType(ctx, pattern); err != nil {
+			return err
 		}
+		subscription.ObjectType = pattern.String()
 	}
 
-	_, err = s.db.NewDelete().
-		Model((*models.SubscriptionChannel)(nil)).
-		Where("subscription_id = ?", id).
-		Exec(ctx)
+	for _, changeType := range subscription.ObjectChangeTypes {
+		if !slices.Contains(ObjectChangeTypes, changeType) {
+			return fmt.Errorf("unknown object change type %q", changeType)
+		}
+	}
+	if len(subscription.ObjectChangeTypes) == 0 {
+		subscription.ObjectChangeTypes = nil
+	}
+
+	return nil
+}
+
+// normalizeBalance checks a balance subscription's address, coin and
+// threshold. When resync is set, the coin's decimals and the current balance
+// are read from chain, and an alert that already holds starts out triggered
+// without notifying.
+func (s *SubscriptionService) normalizeBalance(ctx context.Context, subscription *models.Subscription, resync bool) error {
+	address, err := move.NormalizeAddress(strings.TrimSpace(subscription.BalanceAddress))
+	if err != nil {
+		return fmt.Errorf("invalid balance_address: %w", err)
+	}
+	subscription.BalanceAddress = address
 
+	if subscription.CoinType == "" {
+		subscription.CoinType = "0x2::sui::SUI"
+	}
+	coinType, err := move.ParseStructTag(strings.TrimSpace(subscription.CoinType))
 	if err != nil {
+		return fmt.Errorf("invalid coin type: %w", err)
+	}
+	subscription.CoinType = coinType.String()
+
+	if subscription.Threshold == "" {
+		return errors.New("threshold is required")
+	}
+	if subscription.ThresholdDirection == "" {
+		subscription.ThresholdDirection = models.ThresholdBelow
+	}
+	if subscription.Hysteresis == "" {
+		subscription.Hysteresis = "0"
+	}
+
+	if !resync {
+		_, _, err := balanceLimits(subscription)
 		return err
 	}
 
-	_, err = s.db.NewDelete().
-		Model(subscription).
-		Where("id = ?", id).
-		Exec(ctx)
+	metadata, err := s.coinService.Metadata(ctx, subscription.CoinType)
+	if err != nil {
+		return fmt.Errorf("invalid coin type: %w", err)
+	}
+	subscription.CoinDecimals = metadata.Decimals
 
+	threshold, hysteresis, err := balanceLimits(subscription)
 	if err != nil {
 		return err
 	}
+	subscription.Threshold = formatAmount(threshold, metadata.Decimals)
+	subscription.Hysteresis = formatAmount(hysteresis, metadata.Decimals)
+
+	balance, err := s.coinService.Balance(ctx, subscription.BalanceAddress, subscription.CoinType)
+	if err != nil {
+		return fmt.Errorf("reading balance: %w", err)
+	}
+
+	subscription.Triggered = false
+	_, err = updateBalance(subscription, balance)
+	return err
+}
+
+// normalizePackage checks a package subscription's package and, when resolve
+// is set, finds the UpgradeCap controlling it.
+func (s *SubscriptionService) normalizePackage(ctx context.Context, subscription *models.Subscription, resolve bool) error {
+	if subscription.PackageID == "" {
+		return errors.New("package_id is required")
+	}
+
+	packageID, err := move.NormalizeAddress(strings.TrimSpace(subscription.PackageID))
+	if err != nil {
+		return fmt.Errorf("invalid package_id: %w", err)
+	}
+	subscription.PackageID = packageID
+
+	if !resolve {
+		return nil
+	}
+
+	capID, err := s.packageService.UpgradeCap(ctx, packageID)
+	if err != nil {
+		return fmt.Errorf("invalid package_id: %w", err)
+	}
+	subscription.UpgradeCapID = capID
 
 	return nil
 }
+
+// normalizeSystem checks a system subscription's changes and validators and,
+// when resolve is set, that the validators are in the active set. Without
+// system_events it receives every epoch change, plus the stake movements of
+// its validators.
+func (s *SubscriptionService) normalizeSystem(ctx context.Context, subscription *models.Subscription, resolve bool) error {
+	validators := make([]string, 0, len(subscription.Validators))
+	for _, validator := range subscription.Validators {
+		normalized, err := move.NormalizeAddress(strings.TrimSpace(validator))
+		if err != nil {
+			return fmt.Errorf("invalid validator: %w", err)
+		}
+		if !slices.Contains(validators, normalized) {
+			validators = append(validators, normalized)
+		}
+	}
+
+	if len(validators) > maxWatchedValidators {
+		return fmt.Errorf("at most %d validators can be watched", maxWatchedValidators)
+	}
+
+	subscription.Validators = nil
+	if len(validators) > 0 {
+		subscription.Validators = validators
+	}
+
+	for _, change := range subscription.SystemEvents {
+		if !slices.Contains(SystemEvents, change) {
+			return fmt.Errorf("unknown system event %q", change)
+		}
+	}
+
+	if len(subscription.SystemEvents) == 0 {
+		subscription.SystemEvents = []string{SystemEpochChange, SystemGasPrice, SystemValidatorSet}
+		if len(validators) > 0 {
+			subscription.SystemEvents = append(subscription.SystemEvents, SystemStake)
+		}
+	}
+
+	if slices.Contains(subscription.SystemEvents, SystemStake) && len(validators) == 0 {
+		return errors.New("stake events require validators")
+	}
+
+	if !resolve || len(validators) == 0 {
+		return nil
+	}
+
+	active, err := s.systemService.ActiveValidators(ctx)
+	if err != nil {
+		return fmt.Errorf("looking up validators: %w", err)
+	}
+
+	for _, validator := range validators {
+		if _, ok := active[validator]; !ok {
+			return fmt.Errorf("%s is not an active validator", validator)
+		}
+	}
+
+	return nil
+}
+
+// normalizeAddresses puts the watched addresses of an address subscription in
+// canonical form and defaults its direction to any.
+func normalizeAddresses(subscription *models.Subscription) error {
+	addresses := make([]string, 0, len(subscription.Addresses))
+	for _, address := range subscription.Addresses {
+		normalized, err := move.NormalizeAddress(strings.TrimSpace(address))
+		if err != nil {
+			return fmt.Errorf("invalid address: %w", err)
+		}
+		if !slices.Contains(addresses, normalized) {
+			addresses = append(addresses, normalized)
+		}
+	}
+
+	if len(addresses) == 0 {
+		return errors.New("addresses are required")
+	}
+	if len(addresses) > maxWatchedAddresses {
+		return fmt.Errorf("at most %d addresses can be watched", maxWatchedAddresses)
+	}
+	subscription.Addresses = addresses
+
+	if subscription.AddressDirection == "" {
+		subscription.AddressDirection = models.AddressDirectionAny
+	}
+
+	return nil
+}
+
+// checkFilter compiles a filter expression against the event's field layout,
+// so mistakes surface when the subscription is saved rather than per event.
+func checkFilter(expression string, eventFields []models.EventField) error {
+	if expression == "" {
+		return nil
+	}
+
+	if _, err := filter.Compile(expression, eventFields); err != nil {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+	return nil
+}
+
+// normalizeSourceFilters validates the sender, emitter and transaction kind
+// filters of a subscription and puts addresses in canonical form.
+func normalizeSourceFilters(subscription *models.Subscription) error {
+	senders := make([]string, 0, len(subscription.Senders))
+	for _, sender := range subscription.Senders {
+		address, err := move.NormalizeAddress(strings.TrimSpace(sender))
+		if err != nil {
+			return fmt.Errorf("invalid sender: %w", err)
+		}
+		if !slices.Contains(senders, address) {
+			senders = append(senders, address)
+		}
+	}
+	subscription.Senders = nil
+	if len(senders) > 0 {
+		subscription.Senders = senders
+	}
+
+	if subscription.EmitterPackage != "" {
+		address, err := move.NormalizeAddress(strings.TrimSpace(subscription.EmitterPackage))
+		if err != nil {
+			return fmt.Errorf("invalid emitter package: %w", err)
+		}
+		subscription.EmitterPackage = address
+	}
+
+	if subscription.EmitterModule != "" && subscription.EmitterPackage == "" {
+		return errors.New("emitter_module requires emitter_package")
+	}
+
+	for _, kind := range subscription.TransactionKinds {
+		if !slices.Contains(sui.TransactionKinds, kind) {
+			return fmt.Errorf("unknown transaction kind %q", kind)
+		}
+	}
+	if len(subscription.TransactionKinds) == 0 {
+		subscription.TransactionKinds = nil
+	}
+
+	return nil
+}
+
+// parseEventType checks that an event type is a well-formed event pattern: a
+// Move struct tag, or a package or module with optional wildcards.
+// Subscriptions store its canonical form, so equivalent spellings such as 0x2
+// and 0x0…02 are stored the same way.
+func parseEventType(eventType string) (*move.EventPattern, error) {
+	pattern, err := move.ParseEventPattern(eventType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event type: %w", err)
+	}
+	return pattern, nil
+}
@@ -0,0 +1,428 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/move"
+	"github.com/open-move/intercord/internal/sui"
+)
+
+// System changes a system subscription can receive.
+const (
+	SystemEpochChange  = "epoch_change"
+	SystemGasPrice     = "gas_price"
+	SystemValidatorSet = "validator_set"
+	SystemStake        = "stake"
+)
+
+// SystemEvents are the system changes a subscription can ask for.
+var SystemEvents = []string{SystemEpochChange, SystemGasPrice, SystemValidatorSet, SystemStake}
+
+// Stake movements reported by stake notifications.
+const (
+	StakeAdded     = "staked"
+	StakeWithdrawn = "unstaked"
+)
+
+// SystemService looks up the Sui validator set.
+type SystemService struct {
+	client *sui.Client
+}
+
+func NewSystemService(client *sui.Client) *SystemService {
+	return &SystemService{client: client}
+}
+
+// ActiveValidators returns the validators of the current epoch keyed by their
+// normalized address.
+func (s *SystemService) ActiveValidators(ctx context.Context) (map[string]sui.ValidatorSummary, error) {
+	return activeValidators(ctx, s.client)
+}
+
+func activeValidators(ctx context.Context, client *sui.Client) (map[string]sui.ValidatorSummary, error) {
+	state, err := client.GetLatestSuiSystemState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	validators := make(map[string]sui.ValidatorSummary, len(state.ActiveValidators))
+	for _, validator := range state.ActiveValidators {
+		address, err := move.NormalizeAddress(validator.SuiAddress)
+		if err != nil {
+			return nil, fmt.Errorf("invalid validator address %q: %w", validator.SuiAddress, err)
+		}
+		validators[address] = validator
+	}
+
+	return validators, nil
+}
+
+// ValidatorChange is a validator that joined or left the active set.
+type ValidatorChange struct {
+	Address       string `json:"address"`
+	Name          string `json:"name,omitempty"`
+	StakingPoolID string `json:"staking_pool_id"`
+	Voluntary     *bool  `json:"voluntary,omitempty"`
+}
+
+// EpochChange is the notification payload of a system subscription when an
+// epoch ends. Amounts are in MIST.
+type EpochChange struct {
+	Changes                   []string          `json:"changes"`
+	Epoch                     string            `json:"epoch"`
+	ProtocolVersion           string            `json:"protocol_version"`
+	ReferenceGasPrice         string            `json:"reference_gas_price"`
+	PreviousReferenceGasPrice string            `json:"previous_reference_gas_price,omitempty"`
+	TotalStake                string            `json:"total_stake"`
+	TotalGasFees              string            `json:"total_gas_fees"`
+	StakeSubsidy              string            `json:"stake_subsidy"`
+	StakeRewards              string            `json:"stake_rewards"`
+	StorageFundBalance        string            `json:"storage_fund_balance"`
+	ValidatorsJoined          []ValidatorChange `json:"validators_joined"`
+	ValidatorsLeft            []ValidatorChange `json:"validators_left"`
+	Digest                    string            `json:"digest"`
+	Timestamp                 *time.Time        `json:"timestamp,omitempty"`
+}
+
+// StakeChange is the notification payload of a system subscription when SUI
+// is staked with or withdrawn from a watched validator. Amounts are in MIST.
+type StakeChange struct {
+	Change        string     `json:"change"`
+	Validator     string     `json:"validator"`
+	ValidatorName string     `json:"validator_name,omitempty"`
+	StakingPoolID string     `json:"staking_pool_id"`
+	Staker        string     `json:"staker"`
+	Amount        string     `json:"amount"`
+	Principal     string     `json:"principal,omitempty"`
+	Reward        string     `json:"reward,omitempty"`
+	Epoch         string     `json:"epoch"`
+	Digest        string     `json:"digest"`
+	Timestamp     *time.Time `json:"timestamp,omitempty"`
+}
+
+type epochInfoEvent struct {
+	Epoch              string `json:"epoch"`
+	ProtocolVersion    string `json:"protocol_version"`
+	ReferenceGasPrice  string `json:"reference_gas_price"`
+	TotalStake         string `json:"total_stake"`
+	TotalGasFees       string `json:"total_gas_fees"`
+	StakeSubsidyAmount string `json:"stake_subsidy_amount"`
+	StakeRewards       string `json:"total_stake_rewards_distributed"`
+	StorageFundBalance string `json:"storage_fund_balance"`
+}
+
+type stakeEvent struct {
+	PoolID               string `json:"pool_id"`
+	ValidatorAddress     string `json:"validator_address"`
+	StakerAddress        string `json:"staker_address"`
+	Epoch                string `json:"epoch"`
+	Amount               string `json:"amount"`
+	UnstakingEpoch       string `json:"unstaking_epoch"`
+	PrincipalAmount      string `json:"principal_amount"`
+	RewardAmount         string `json:"reward_amount"`
+	StakeActivationEpoch string `json:"stake_activation_epoch"`
+}
+
+type validatorSetEvent struct {
+	ValidatorAddress string `json:"validator_address"`
+	StakingPoolID    string `json:"staking_pool_id"`
+	IsVoluntary      *bool  `json:"is_voluntary"`
+}
+
+// PollSystem reads the epoch changes and stake movements since the last poll
+// and queues a notification for every system subscription they concern.
+func (s *IngestionService) PollSystem(ctx context.Context) error {
+	subscriptions, err := s.activeSubscriptions(ctx, models.SubscriptionKindSystem)
+	if err != nil || len(subscriptions) == 0 {
+		return err
+	}
+
+	ids := make([]int64, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		ids = append(ids, subscription.ID)
+	}
+
+	channels, err := s.subscriptionChannels(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	// Full nodes answer one event type per query, so each is read on its own
+	// stream with its own cursor.
+	streams := map[string]string{"system:epoch": sui.SystemEpochInfoEventType}
+	for _, subscription := range subscriptions {
+		// Stake requests are frequent, so they are only read while someone
+		// watches them.
+		if slices.Contains(subscription.SystemEvents, SystemStake) {
+			streams["system:stake"] = sui.StakingRequestEventType
+			streams["system:unstake"] = sui.UnstakingRequestEventType
+			break
+		}
+	}
+
+	since := subscriptions[0].CreatedAt
+	for _, subscription := range subscriptions {
+		if subscription.CreatedAt.Before(since) {
+			since = subscription.CreatedAt
+		}
+	}
+
+	names := make([]string, 0, len(streams))
+	for name := range streams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		err := s.pollEventStream(ctx, name, sui.EventTypeEvents(streams[name]), since, func(events []sui.Event, from, to *models.IngestionCursor) error {
+			return s.ingestSystem(ctx, name, subscriptions, channels, events, from, to)
+		})
+		if err != nil {
+			log.Printf("Polling %s failed: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// ingestSystem builds the payloads of a page before opening the database
+// transaction, since describing an epoch change calls the node.
+func (s *IngestionService) ingestSystem(ctx context.Context, stream string, subscriptions []models.Subscription, channels map[int64][]int64, events []sui.Event, from, to *models.IngestionCursor) error {
+	names := &validatorNames{client: s.client}
+
+	var queued []queuedPayload
+	for _, event := range events {
+		var payload interface{}
+		var changes []string
+		var validator string
+
+		switch {
+		case sameStructType(event.Type, canonicalType(sui.SystemEpochInfoEventType)):
+			change, err := s.epochChange(ctx, event, names)
+			if err != nil {
+				return err
+			}
+			payload, changes = change, change.Changes
+
+		case sameStructType(event.Type, canonicalType(sui.StakingRequestEventType)),
+			sameStructType(event.Type, canonicalType(sui.UnstakingRequestEventType)):
+			change, err := stakeChange(ctx, event, names)
+			if err != nil {
+				log.Printf("Skipping stake event %s/%s: %v", event.ID.TxDigest, event.ID.EventSeq, err)
+				continue
+			}
+			payload, changes, validator = change, []string{SystemStake}, change.Validator
+
+		default:
+			continue
+		}
+
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+		for i := range subscriptions {
+			subscription := &subscriptions[i]
+			if emitted := event.Timestamp(); !emitted.IsZero() && emitted.Before(subscription.CreatedAt) {
+				continue
+			}
+
+			if !wantsSystemChange(subscription, changes, validator) {
+				continue
+			}
+			queued = append(queued, queuedPayload{subscription: subscription, payload: encoded})
+		}
+	}
+
+	return s.commit(ctx, stream, from, to, func(queue *notificationQueue) error {
+		for _, q := range queued {
+			if err := queue.add(q.subscription, channels[q.subscription.ID], q.payload); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// wantsSystemChange reports whether a subscription asked for any of changes.
+// Stake movements only go to subscriptions watching their validator.
+func wantsSystemChange(subscription *models.Subscription, changes []string, validator string) bool {
+	if validator != "" && !slices.ContainsFunc(subscription.Validators, func(watched string) bool {
+		return sameAddress(validator, watched)
+	}) {
+		return false
+	}
+
+	for _, change := range changes {
+		if slices.Contains(subscription.SystemEvents, change) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *IngestionService) epochChange(ctx context.Context, event sui.Event, names *validatorNames) (*EpochChange, error) {
+	var info epochInfoEvent
+	if err := json.Unmarshal(event.ParsedJSON, &info); err != nil {
+		return nil, fmt.Errorf("decoding epoch info %s: %w", event.ID.TxDigest, err)
+	}
+
+	change := &EpochChange{
+		Changes:            []string{SystemEpochChange},
+		Epoch:              info.Epoch,
+		ProtocolVersion:    info.ProtocolVersion,
+		ReferenceGasPrice:  info.ReferenceGasPrice,
+		TotalStake:         info.TotalStake,
+		TotalGasFees:       info.TotalGasFees,
+		StakeSubsidy:       info.StakeSubsidyAmount,
+		StakeRewards:       info.StakeRewards,
+		StorageFundBalance: info.StorageFundBalance,
+		ValidatorsJoined:   []ValidatorChange{},
+		ValidatorsLeft:     []ValidatorChange{},
+		Digest:             event.ID.TxDigest,
+	}
+
+	if emitted := event.Timestamp(); !emitted.IsZero() {
+		change.Timestamp = &emitted
+	}
+
+	previous, err := s.client.QueryEvents(ctx, sui.EventTypeEvents(sui.SystemEpochInfoEventType), &event.ID, 1, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(previous.Data) > 0 {
+		var before epochInfoEvent
+		if err := json.Unmarshal(previous.Data[0].ParsedJSON, &before); err == nil {
+			change.PreviousReferenceGasPrice = before.ReferenceGasPrice
+			if before.ReferenceGasPrice != info.ReferenceGasPrice {
+				change.Changes = append(change.Changes, SystemGasPrice)
+			}
+		}
+	}
+
+	// The validators joining and leaving are processed by the same
+	// transaction that ends the epoch.
+	events, err := s.client.GetEvents(ctx, event.ID.TxDigest)
+	if isRPCError(err) {
+		log.Printf("Failed to look up the validator set changes of %s: %v", event.ID.TxDigest, err)
+		return change, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, txEvent := range events {
+		joined := sameStructType(txEvent.Type, canonicalType(sui.ValidatorJoinEventType))
+		if !joined && !sameStructType(txEvent.Type, canonicalType(sui.ValidatorLeaveEventType)) {
+			continue
+		}
+
+		var set validatorSetEvent
+		if err := json.Unmarshal(txEvent.ParsedJSON, &set); err != nil {
+			return nil, fmt.Errorf("decoding validator set change %s: %w", event.ID.TxDigest, err)
+		}
+
+		validator := ValidatorChange{
+			Address:       normalizedOr(set.ValidatorAddress),
+			StakingPoolID: set.StakingPoolID,
+		}
+
+		if joined {
+			validator.Name = names.lookup(ctx, validator.Address)
+			change.ValidatorsJoined = append(change.ValidatorsJoined, validator)
+		} else {
+			validator.Voluntary = set.IsVoluntary
+			change.ValidatorsLeft = append(change.ValidatorsLeft, validator)
+		}
+	}
+
+	if len(change.ValidatorsJoined) > 0 || len(change.ValidatorsLeft) > 0 {
+		change.Changes = append(change.Changes, SystemValidatorSet)
+	}
+
+	return change, nil
+}
+
+func stakeChange(ctx context.Context, event sui.Event, names *validatorNames) (*StakeChange, error) {
+	var stake stakeEvent
+	if err := json.Unmarshal(event.ParsedJSON, &stake); err != nil {
+		return nil, err
+	}
+
+	change := &StakeChange{
+		Change:        StakeAdded,
+		Validator:     normalizedOr(stake.ValidatorAddress),
+		StakingPoolID: stake.PoolID,
+		Staker:        normalizedOr(stake.StakerAddress),
+		Amount:        stake.Amount,
+		Epoch:         stake.Epoch,
+		Digest:        event.ID.TxDigest,
+	}
+
+	if stake.UnstakingEpoch != "" {
+		change.Change = StakeWithdrawn
+		change.Principal = stake.PrincipalAmount
+		change.Reward = stake.RewardAmount
+		change.Epoch = stake.UnstakingEpoch
+
+		principal, err := parseAmount(stake.PrincipalAmount, 0)
+		if err != nil {
+			return nil, err
+		}
+		reward, err := parseAmount(stake.RewardAmount, 0)
+		if err != nil {
+			return nil, err
+		}
+		change.Amount = principal.Add(principal, reward).String()
+	}
+
+	if emitted := event.Timestamp(); !emitted.IsZero() {
+		change.Timestamp = &emitted
+	}
+
+	change.ValidatorName = names.lookup(ctx, change.Validator)
+	return change, nil
+}
+
+// validatorNames looks up the validator set at most once per page. Names are
+// left out when the node can't be reached or the validator is no longer
+// active.
+type validatorNames struct {
+	client     *sui.Client
+	validators map[string]sui.ValidatorSummary
+	fetched    bool
+}
+
+func (n *validatorNames) lookup(ctx context.Context, address string) string {
+	if !n.fetched {
+		n.fetched = true
+
+		validators, err := activeValidators(ctx, n.client)
+		if err != nil {
+			log.Printf("Failed to look up the validator set: %v", err)
+		}
+		n.validators = validators
+	}
+
+	return n.validators[address].Name
+}
+
+func canonicalType(typ string) string {
+	tag, _ := move.ParseStructTag(typ)
+	return tag.String()
+}
+
+func normalizedOr(address string) string {
+	normalized, err := move.NormalizeAddress(address)
+	if err != nil {
+		return address
+	}
+	return normalized
+}
@@ -2,29 +2,61 @@ package services
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
 	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/uptrace/bun"
 
+	"github.com/open-move/intercord/internal/authz"
+	"github.com/open-move/intercord/internal/config"
 	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/utils"
 )
 
+const invitationTokenScope = "team-invitation"
+
 type TeamService struct {
 	db           *bun.DB
+	authConfig   *config.AuthConfig
+	teamsConfig  *config.TeamsConfig
+	secret       string
 	emailService *EmailService
+	auditService *AuditService
+	quotaService *QuotaService
 }
 
-func NewTeamService(db *bun.DB, emailService *EmailService) *TeamService {
+func NewTeamService(db *bun.DB, authConfig *config.AuthConfig, teamsConfig *config.TeamsConfig, secret string, emailService *EmailService, auditService *AuditService, quotaService *QuotaService) *TeamService {
 	return &TeamService{
 		db:           db,
+		authConfig:   authConfig,
+		teamsConfig:  teamsConfig,
+		secret:       secret,
 		emailService: emailService,
+		auditService: auditService,
+		quotaService: quotaService,
 	}
 }
 
 type CreateTeamInput struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
+	Name           string   `json:"name" binding:"required"`
+	Description    string   `json:"description"`
+	JoinPolicy     string   `json:"join_policy" binding:"omitempty,oneof=invite_only request domain"`
+	AllowedDomains []string `json:"allowed_domains"`
+}
+
+type UpdateJoinPolicyInput struct {
+	JoinPolicy     string   `json:"join_policy" binding:"required,oneof=invite_only request domain"`
+	AllowedDomains []string `json:"allowed_domains"`
+}
+
+type JoinTeamInput struct {
+	Message string `json:"message" binding:"max=500"`
 }
 
 type InviteToTeamInput struct {
@@ -34,13 +66,26 @@ type InviteToTeamInput struct {
 }
 
 func (s *TeamService) Create(ctx context.Context, input CreateTeamInput, userID int64) (*models.Team, error) {
+	joinPolicy := models.TeamJoinPolicyInviteOnly
+	if input.JoinPolicy != "" {
+		joinPolicy = models.TeamJoinPolicy(input.JoinPolicy)
+	}
+
+	allowedDomains, err := normalizeDomains(joinPolicy, input.AllowedDomains)
+	if err != nil {
+		return nil, err
+	}
+
 	team := &models.Team{
-		Name:        input.Name,
-		Description: input.Description,
-		OwnerID:     userID,
+		Name:           input.Name,
+		Description:    input.Description,
+		OwnerID:        userID,
+		JoinPolicy:     joinPolicy,
+		AllowedDomains: allowedDomains,
+		Plan:           models.TeamPlan(s.teamsConfig.DefaultPlan),
 	}
 
-	_, err := s.db.NewInsert().Model(team).Exec(ctx)
+	_, err = s.db.NewInsert().Model(team).Exec(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -70,11 +115,14 @@ func (s *TeamService) GetByID(ctx context.Context, id int64) (*models.Team, erro
 
 func (s *TeamService) GetTeamsByUserID(ctx context.Context, userID int64) ([]models.Team, error) {
 	var teams []models.Team
+	memberships := s.db.NewSelect().
+		Model((*models.TeamMembership)(nil)).
+		Column("team_id").
+		Where("user_id = ?", userID)
+
 	err := s.db.NewSelect().
 		Model(&teams).
-		Join("JOIN team_memberships AS tm ON tm.team_id = team.id").
-		Where("tm.user_id = ?", userID).
-		Where("tm.deleted_at IS NULL").
+		Where("t.id IN (?)", memberships).
 		Scan(ctx)
 
 	if err != nil {
@@ -88,8 +136,10 @@ func (s *TeamService) GetMembership(ctx context.Context, teamID, userID int64) (
 	membership := new(models.TeamMembership)
 	err := s.db.NewSelect().
 		Model(membership).
-		Where("team_id = ?", teamID).
-		Where("user_id = ?", userID).
+		Relation("CustomRole").
+		Where("tm.team_id = ?", teamID).
+		Where("tm.user_id = ?", userID).
+		Where("tm.team_id IN (?)", s.db.NewSelect().Model((*models.Team)(nil)).Column("id")).
 		Scan(ctx)
 
 	if err != nil {
@@ -99,86 +149,480 @@ func (s *TeamService) GetMembership(ctx context.Context, teamID, userID int64) (
 	return membership, nil
 }
 
-func (s *TeamService) InviteToTeam(ctx context.Context, input InviteToTeamInput, inviterID int64, baseURL string) error {
+func (s *TeamService) InviteToTeam(ctx context.Context, input InviteToTeamInput, inviterID int64, baseURL string) (*models.TeamInvitation, error) {
+	email := strings.ToLower(strings.TrimSpace(input.Email))
 
-	inviterMembership := new(models.TeamMembership)
-	err := s.db.NewSelect().
-		Model(inviterMembership).
+	if err := s.checkGrant(ctx, input.TeamID, inviterID, models.TeamRole(input.Role)); err != nil {
+		return nil, err
+	}
+
+	isMember, err := s.db.NewSelect().
+		Model((*models.TeamMembership)(nil)).
+		Join("JOIN users AS u ON u.id = tm.user_id").
+		Where("tm.team_id = ?", input.TeamID).
+		Where("LOWER(u.email) = ?", email).
+		Exists(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if isMember {
+		return nil, errors.New("user is already a member of this team")
+	}
+
+	alreadyInvited, err := s.db.NewSelect().
+		Model((*models.TeamInvitation)(nil)).
 		Where("team_id = ?", input.TeamID).
-		Where("user_id = ?", inviterID).
+		Where("email = ?", email).
+		Where("status = ?", models.TeamInvitationStatusPending).
+		Where("expires_at > ?", time.Now()).
+		Exists(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if alreadyInvited {
+		return nil, errors.New("this email already has a pending invitation; resend it instead")
+	}
+
+	nonce, err := utils.GenerateRandomToken(24)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &models.TeamInvitation{
+		TeamID:      input.TeamID,
+		Email:       email,
+		Role:        models.TeamRole(input.Role),
+		Nonce:       nonce,
+		Status:      models.TeamInvitationStatusPending,
+		InvitedByID: inviterID,
+		ExpiresAt:   time.Now().Add(s.teamsConfig.InvitationTTL),
+	}
+
+	_, err = s.db.NewInsert().Model(invitation).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit(ctx, s.db, input.TeamID, inviterID, models.AuditMemberInvited, "invitation", invitation.ID, map[string]models.AuditChange{
+		"email": {To: invitation.Email},
+		"role":  {To: invitation.Role},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sendInvitation(ctx, invitation, baseURL); err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (s *TeamService) GetTeamInvitations(ctx context.Context, teamID int64) ([]models.TeamInvitation, error) {
+	var invitations []models.TeamInvitation
+	err := s.db.NewSelect().
+		Model(&invitations).
+		Where("team_id = ?", teamID).
+		Order("created_at DESC").
 		Scan(ctx)
 
 	if err != nil {
-		return errors.New("you are not a member of this team")
+		return nil, err
 	}
 
-	if inviterMembership.Role != models.TeamRoleOwner && inviterMembership.Role != models.TeamRoleAdmin {
-		return errors.New("you don't have permission to invite members")
+	for i := range invitations {
+		invitations[i].Status = effectiveInvitationStatus(&invitations[i])
 	}
 
+	return invitations, nil
+}
+
+func (s *TeamService) GetUserInvitations(ctx context.Context, userID int64) ([]models.TeamInvitation, error) {
 	user := new(models.User)
-	err = s.db.NewSelect().Model(user).Where("email = ?", input.Email).Scan(ctx)
+	err := s.db.NewSelect().Model(user).Where("id = ?", userID).Scan(ctx)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	var invitations []models.TeamInvitation
+	err = s.db.NewSelect().
+		Model(&invitations).
+		Relation("Team").
+		Relation("InvitedBy").
+		Where("ti.email = ?", strings.ToLower(user.Email)).
+		Where("ti.status = ?", models.TeamInvitationStatusPending).
+		Where("ti.expires_at > ?", time.Now()).
+		Order("ti.created_at DESC").
+		Scan(ctx)
 
 	if err != nil {
+		return nil, err
+	}
 
-		return errors.New("user with this email does not exist")
+	for i := range invitations {
+		invitations[i].Token = s.invitationToken(&invitations[i])
 	}
 
-	existingMembership := new(models.TeamMembership)
-	err = s.db.NewSelect().
-		Model(existingMembership).
-		Where("team_id = ?", input.TeamID).
-		Where("user_id = ?", user.ID).
+	return invitations, nil
+}
+
+func (s *TeamService) AcceptInvitation(ctx context.Context, token string, userID int64) (*models.TeamMembership, error) {
+	invitation, user, err := s.findInvitationForUser(ctx, token, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.authConfig.RequireEmailVerification && !user.Verified {
+		return nil, errors.New("please verify your email address before accepting invitations")
+	}
+
+	var membership *models.TeamMembership
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var err error
+		membership, err = s.acceptInvitation(ctx, tx, invitation, user.ID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+func (s *TeamService) DeclineInvitation(ctx context.Context, token string, userID int64) error {
+	invitation, _, err := s.findInvitationForUser(ctx, token, userID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err = s.db.NewUpdate().
+		Model((*models.TeamInvitation)(nil)).
+		Set("status = ?", models.TeamInvitationStatusDeclined).
+		Set("responded_at = ?", now).
+		Set("updated_at = ?", now).
+		Where("id = ?", invitation.ID).
+		Exec(ctx)
+
+	return err
+}
+
+func (s *TeamService) ResendInvitation(ctx context.Context, teamID, invitationID, userID int64, baseURL string) error {
+	invitation := new(models.TeamInvitation)
+	err := s.db.NewSelect().
+		Model(invitation).
+		Where("id = ?", invitationID).
+		Where("team_id = ?", teamID).
 		Scan(ctx)
 
-	if err == nil {
-		return errors.New("user is already a member of this team")
+	if err != nil {
+		return errors.New("invitation not found")
 	}
 
-	team := new(models.Team)
-	err = s.db.NewSelect().Model(team).Where("id = ?", input.TeamID).Scan(ctx)
+	status := effectiveInvitationStatus(invitation)
+	if status != models.TeamInvitationStatusPending && status != models.TeamInvitationStatusExpired {
+		return fmt.Errorf("invitation has already been %s", status)
+	}
+
+	if err := s.checkGrant(ctx, teamID, userID, invitation.Role); err != nil {
+		return err
+	}
+
+	nonce, err := utils.GenerateRandomToken(24)
 	if err != nil {
 		return err
 	}
 
-	inviter := new(models.User)
-	err = s.db.NewSelect().Model(inviter).Where("id = ?", inviterID).Scan(ctx)
+	invitation.Nonce = nonce
+	invitation.Status = models.TeamInvitationStatusPending
+	invitation.ExpiresAt = time.Now().Add(s.teamsConfig.InvitationTTL)
+	invitation.UpdatedAt = time.Now()
+
+	_, err = s.db.NewUpdate().
+		Model(invitation).
+		Column("nonce", "status", "expires_at", "updated_at").
+		Where("id = ?", invitation.ID).
+		Exec(ctx)
+
 	if err != nil {
 		return err
 	}
 
-	role := models.TeamRoleMember
-	if input.Role == "admin" {
-		role = models.TeamRoleAdmin
+	err = s.audit(ctx, s.db, teamID, userID, models.AuditInvitationResent, "invitation", invitation.ID, map[string]models.AuditChange{
+		"email": {To: invitation.Email},
+	})
+	if err != nil {
+		return err
 	}
 
-	membership := &models.TeamMembership{
-		TeamID: input.TeamID,
-		UserID: user.ID,
-		Role:   role,
+	return s.sendInvitation(ctx, invitation, baseURL)
+}
+
+func (s *TeamService) RevokeInvitation(ctx context.Context, teamID, invitationID, userID int64) error {
+	result, err := s.db.NewUpdate().
+		Model((*models.TeamInvitation)(nil)).
+		Set("status = ?", models.TeamInvitationStatusRevoked).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", invitationID).
+		Where("team_id = ?", teamID).
+		Where("status = ?", models.TeamInvitationStatusPending).
+		Exec(ctx)
+
+	if err != nil {
+		return err
 	}
 
-	_, err = s.db.NewInsert().Model(membership).Exec(ctx)
+	affected, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
 
-	inviteLink := fmt.Sprintf("%s/teams/%d", baseURL, input.TeamID)
-	inviterName := fmt.Sprintf("%s %s", inviter.FirstName, inviter.LastName)
-	err = s.emailService.SendTeamInviteEmail(user.Email, inviterName, team.Name, inviteLink)
+	if affected == 0 {
+		return errors.New("pending invitation not found")
+	}
+
+	return s.audit(ctx, s.db, teamID, userID, models.AuditInvitationRevoked, "invitation", invitationID, nil)
+}
+
+func (s *TeamService) ApplyPendingInvitations(ctx context.Context, user *models.User) error {
+	var invitations []models.TeamInvitation
+	err := s.db.NewSelect().
+		Model(&invitations).
+		Where("email = ?", strings.ToLower(user.Email)).
+		Where("status = ?", models.TeamInvitationStatusPending).
+		Where("expires_at > ?", time.Now()).
+		Scan(ctx)
+
 	if err != nil {
 		return err
 	}
 
+	for i := range invitations {
+		err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			_, err := s.acceptInvitation(ctx, tx, &invitations[i], user.ID)
+			return err
+		})
+
+		if err != nil {
+			log.Printf("Failed to apply invitation %d for user %d: %v", invitations[i].ID, user.ID, err)
+		}
+	}
+
 	return nil
 }
 
-func (s *TeamService) JoinTeam(ctx context.Context, teamID, userID int64) error {
+func (s *TeamService) ExpireInvitations(ctx context.Context) error {
+	_, err := s.db.NewUpdate().
+		Model((*models.TeamInvitation)(nil)).
+		Set("status = ?", models.TeamInvitationStatusExpired).
+		Set("updated_at = ?", time.Now()).
+		Where("status = ?", models.TeamInvitationStatusPending).
+		Where("expires_at < ?", time.Now()).
+		Exec(ctx)
+
+	return err
+}
+
+func (s *TeamService) acceptInvitation(ctx context.Context, tx bun.Tx, invitation *models.TeamInvitation, userID int64) (*models.TeamMembership, error) {
+	if err := s.quotaService.CheckLimit(ctx, tx, invitation.TeamID, QuotaMembers, 1); err != nil {
+		return nil, err
+	}
+
+	exists, err := tx.NewSelect().
+		Model((*models.TeamMembership)(nil)).
+		Where("team_id = ?", invitation.TeamID).
+		Where("user_id = ?", userID).
+		Exists(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		return nil, errors.New("you are already a member of this team")
+	}
+
+	now := time.Now()
+	result, err := tx.NewUpdate().
+		Model((*models.TeamInvitation)(nil)).
+		Set("status = ?", models.TeamInvitationStatusAccepted).
+		Set("responded_at = ?", now).
+		Set("updated_at = ?", now).
+		Where("id = ?", invitation.ID).
+		Where("status = ?", models.TeamInvitationStatusPending).
+		Exec(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return nil, errors.New("invitation is no longer pending")
+	}
+
+	membership := &models.TeamMembership{
+		TeamID: invitation.TeamID,
+		UserID: userID,
+		Role:   invitation.Role,
+	}
+
+	_, err = tx.NewInsert().Model(membership).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit(ctx, tx, invitation.TeamID, userID, models.AuditMemberJoined, "member", userID, map[string]models.AuditChange{
+		"role":          {To: membership.Role},
+		"invitation_id": {To: invitation.ID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+// GetInvitation returns the pending invitation a signed token points to, so
+// the invitee can see what they were invited to before accepting.
+func (s *TeamService) GetInvitation(ctx context.Context, token string) (*models.TeamInvitation, error) {
+	invitation, err := s.findInvitation(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.NewSelect().
+		Model(invitation).
+		Relation("Team").
+		Relation("InvitedBy").
+		WherePK().
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (s *TeamService) findInvitation(ctx context.Context, token string) (*models.TeamInvitation, error) {
+	value, ok := utils.ParseSignedToken(s.secret, invitationTokenScope, token)
+	if !ok {
+		return nil, errors.New("invalid invitation token")
+	}
+
+	idStr, nonce, found := strings.Cut(value, ".")
+	invitationID, err := strconv.ParseInt(idStr, 10, 64)
+	if !found || err != nil {
+		return nil, errors.New("invalid invitation token")
+	}
+
+	invitation := new(models.TeamInvitation)
+	err = s.db.NewSelect().Model(invitation).Where("id = ?", invitationID).Scan(ctx)
+	if err != nil || subtle.ConstantTimeCompare([]byte(invitation.Nonce), []byte(nonce)) != 1 {
+		return nil, errors.New("invalid invitation token")
+	}
+
+	status := effectiveInvitationStatus(invitation)
+	if status != models.TeamInvitationStatusPending {
+		return nil, fmt.Errorf("invitation is %s", status)
+	}
+
+	return invitation, nil
+}
+
+func (s *TeamService) findInvitationForUser(ctx context.Context, token string, userID int64) (*models.TeamInvitation, *models.User, error) {
+	invitation, err := s.findInvitation(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user := new(models.User)
+	err = s.db.NewSelect().Model(user).Where("id = ?", userID).Scan(ctx)
+	if err != nil {
+		return nil, nil, errors.New("user not found")
+	}
+
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		return nil, nil, errors.New("this invitation was sent to a different email address")
+	}
+
+	return invitation, user, nil
+}
+
+func (s *TeamService) sendInvitation(ctx context.Context, invitation *models.TeamInvitation, baseURL string) error {
+	team := new(models.Team)
+	err := s.db.NewSelect().Model(team).Where("id = ?", invitation.TeamID).Scan(ctx)
+	if err != nil {
+		return err
+	}
+
+	inviter := new(models.User)
+	err = s.db.NewSelect().Model(inviter).Where("id = ?", invitation.InvitedByID).Scan(ctx)
+	if err != nil {
+		return err
+	}
+
+	inviteLink := fmt.Sprintf("%s/invitations?token=%s", baseURL, url.QueryEscape(s.invitationToken(invitation)))
+	inviterName := fmt.Sprintf("%s %s", inviter.FirstName, inviter.LastName)
+	return s.emailService.SendTeamInviteEmail(invitation.Email, inviterName, team.Name, inviteLink)
+}
+
+func (s *TeamService) invitationToken(invitation *models.TeamInvitation) string {
+	return utils.SignToken(s.secret, invitationTokenScope, fmt.Sprintf("%d.%s", invitation.ID, invitation.Nonce))
+}
+
+func effectiveInvitationStatus(invitation *models.TeamInvitation) models.TeamInvitationStatus {
+	if invitation.Status == models.TeamInvitationStatusPending && invitation.ExpiresAt.Before(time.Now()) {
+		return models.TeamInvitationStatusExpired
+	}
+	return invitation.Status
+}
+
+func (s *TeamService) UpdateJoinPolicy(ctx context.Context, teamID, userID int64, input UpdateJoinPolicyInput) (*models.Team, error) {
+	team, err := s.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, errors.New("team not found")
+	}
+
+	before := map[string]interface{}{"join_policy": team.JoinPolicy, "allowed_domains": team.AllowedDomains}
+
+	team.JoinPolicy = models.TeamJoinPolicy(input.JoinPolicy)
+	team.AllowedDomains, err = normalizeDomains(team.JoinPolicy, input.AllowedDomains)
+	if err != nil {
+		return nil, err
+	}
+	team.UpdatedAt = time.Now()
+
+	_, err = s.db.NewUpdate().Model(team).
+		Column("join_policy", "allowed_domains", "updated_at").
+		Where("id = ?", teamID).
+		Exec(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	changes := auditDiff(before, map[string]interface{}{"join_policy": team.JoinPolicy, "allowed_domains": team.AllowedDomains})
+	if len(changes) > 0 {
+		if err := s.audit(ctx, s.db, teamID, userID, models.AuditTeamJoinPolicyChanged, "team", teamID, changes); err != nil {
+			return nil, err
+		}
+	}
+
+	return team, nil
+}
+
+func (s *TeamService) JoinTeam(ctx context.Context, teamID, userID int64, input JoinTeamInput, baseURL string) (*models.TeamMembership, *models.TeamJoinRequest, error) {
 
 	team := new(models.Team)
 	err := s.db.NewSelect().Model(team).Where("id = ?", teamID).Scan(ctx)
 	if err != nil {
-		return errors.New("team not found")
+		return nil, nil, errors.New("team not found")
 	}
 
 	membership := new(models.TeamMembership)
@@ -189,21 +633,255 @@ func (s *TeamService) JoinTeam(ctx context.Context, teamID, userID int64) error
 		Scan(ctx)
 
 	if err == nil {
-		return errors.New("you are already a member of this team")
+		return nil, nil, errors.New("you are already a member of this team")
 	}
 
-	newMembership := &models.TeamMembership{
-		TeamID: teamID,
-		UserID: userID,
-		Role:   models.TeamRoleMember,
+	user := new(models.User)
+	err = s.db.NewSelect().Model(user).Where("id = ?", userID).Scan(ctx)
+	if err != nil {
+		return nil, nil, errors.New("user not found")
+	}
+
+	switch team.JoinPolicy {
+	case models.TeamJoinPolicyDomain:
+		if !user.Verified {
+			return nil, nil, errors.New("please verify your email address before joining this team")
+		}
+
+		if !emailInDomains(user.Email, team.AllowedDomains) {
+			return nil, nil, errors.New("your email domain is not allowed to join this team")
+		}
+
+		newMembership := &models.TeamMembership{
+			TeamID: teamID,
+			UserID: userID,
+			Role:   models.TeamRoleMember,
+		}
+
+		err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			if err := s.quotaService.CheckLimit(ctx, tx, teamID, QuotaMembers, 1); err != nil {
+				return err
+			}
+
+			if _, err := tx.NewInsert().Model(newMembership).Exec(ctx); err != nil {
+				return err
+			}
+
+			return s.audit(ctx, tx, teamID, userID, models.AuditMemberJoined, "member", userID, map[string]models.AuditChange{
+				"role":  {To: newMembership.Role},
+				"email": {To: user.Email},
+			})
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return newMembership, nil, nil
+
+	case models.TeamJoinPolicyRequest:
+		pending, err := s.db.NewSelect().
+			Model((*models.TeamJoinRequest)(nil)).
+			Where("team_id = ?", teamID).
+			Where("user_id = ?", userID).
+			Where("status = ?", models.TeamJoinRequestStatusPending).
+			Exists(ctx)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if pending {
+			return nil, nil, errors.New("you already have a pending request to join this team")
+		}
+
+		request := &models.TeamJoinRequest{
+			TeamID:  teamID,
+			UserID:  userID,
+			Message: input.Message,
+			Status:  models.TeamJoinRequestStatusPending,
+		}
+
+		_, err = s.db.NewInsert().Model(request).Exec(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		s.notifyAdminsOfJoinRequest(ctx, team, user, baseURL)
+
+		return nil, request, nil
+
+	default:
+		return nil, nil, errors.New("this team is invite-only")
+	}
+}
+
+func (s *TeamService) GetJoinRequests(ctx context.Context, teamID int64) ([]models.TeamJoinRequest, error) {
+	var requests []models.TeamJoinRequest
+	err := s.db.NewSelect().
+		Model(&requests).
+		Relation("User").
+		Where("tjr.team_id = ?", teamID).
+		Where("tjr.status = ?", models.TeamJoinRequestStatusPending).
+		Order("tjr.created_at ASC").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
 	}
 
-	_, err = s.db.NewInsert().Model(newMembership).Exec(ctx)
+	return requests, nil
+}
+
+func (s *TeamService) ReviewJoinRequest(ctx context.Context, teamID, requestID, userID int64, approve bool) (*models.TeamJoinRequest, error) {
+	request := new(models.TeamJoinRequest)
+	err := s.db.NewSelect().
+		Model(request).
+		Relation("User").
+		Relation("Team").
+		Where("tjr.id = ?", requestID).
+		Where("tjr.team_id = ?", teamID).
+		Scan(ctx)
+
 	if err != nil {
+		return nil, errors.New("join request not found")
+	}
+
+	if request.Status != models.TeamJoinRequestStatusPending {
+		return nil, fmt.Errorf("join request has already been %s", request.Status)
+	}
+
+	now := time.Now()
+	request.Status = models.TeamJoinRequestStatusRejected
+	if approve {
+		request.Status = models.TeamJoinRequestStatusApproved
+	}
+	request.ReviewedByID = &userID
+	request.ReviewedAt = &now
+	request.UpdatedAt = now
+
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewUpdate().
+			Model(request).
+			Column("status", "reviewed_by_id", "reviewed_at", "updated_at").
+			Where("id = ?", request.ID).
+			Where("status = ?", models.TeamJoinRequestStatusPending).
+			Exec(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			return errors.New("join request is no longer pending")
+		}
+
+		action := models.AuditJoinRequestRejected
+		if approve {
+			action = models.AuditJoinRequestApproved
+		}
+
+		err = s.audit(ctx, tx, teamID, userID, action, "join_request", request.ID, map[string]models.AuditChange{
+			"user_id": {To: request.UserID},
+		})
+		if err != nil || !approve {
+			return err
+		}
+
+		exists, err := tx.NewSelect().
+			Model((*models.TeamMembership)(nil)).
+			Where("team_id = ?", teamID).
+			Where("user_id = ?", request.UserID).
+			Exists(ctx)
+
+		if err != nil || exists {
+			return err
+		}
+
+		if err := s.quotaService.CheckLimit(ctx, tx, teamID, QuotaMembers, 1); err != nil {
+			return err
+		}
+
+		membership := &models.TeamMembership{
+			TeamID: teamID,
+			UserID: request.UserID,
+			Role:   models.TeamRoleMember,
+		}
+
+		_, err = tx.NewInsert().Model(membership).Exec(ctx)
 		return err
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	if request.User != nil && request.Team != nil {
+		if err := s.emailService.SendJoinRequestDecisionEmail(request.User.Email, request.Team.Name, approve); err != nil {
+			log.Printf("Failed to notify user %d of join request decision: %v", request.UserID, err)
+		}
+	}
+
+	return request, nil
+}
+
+func (s *TeamService) notifyAdminsOfJoinRequest(ctx context.Context, team *models.Team, requester *models.User, baseURL string) {
+	var members []models.TeamMembership
+	err := s.db.NewSelect().
+		Model(&members).
+		Relation("User").
+		Relation("CustomRole").
+		Where("tm.team_id = ?", team.ID).
+		Scan(ctx)
+
+	if err != nil {
+		log.Printf("Failed to load reviewers of team %d: %v", team.ID, err)
+		return
+	}
+
+	reviewLink := fmt.Sprintf("%s/teams/%d/join-requests", baseURL, team.ID)
+	requesterName := fmt.Sprintf("%s %s", requester.FirstName, requester.LastName)
+	for _, member := range members {
+		if member.User == nil || !authz.Allows(&member, authz.TeamManageMembers) {
+			continue
+		}
+
+		if err := s.emailService.SendJoinRequestEmail(member.User.Email, requesterName, team.Name, reviewLink); err != nil {
+			log.Printf("Failed to notify user %d of join request: %v", member.UserID, err)
+		}
+	}
+}
+
+func normalizeDomains(policy models.TeamJoinPolicy, domains []string) ([]string, error) {
+	var normalized []string
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+		if domain == "" || strings.ContainsAny(domain, "@ /") || !strings.Contains(domain, ".") {
+			return nil, fmt.Errorf("invalid email domain %q", domain)
+		}
+		normalized = append(normalized, domain)
+	}
+
+	if policy == models.TeamJoinPolicyDomain && len(normalized) == 0 {
+		return nil, errors.New("at least one allowed email domain is required for the domain join policy")
+	}
+
+	return normalized, nil
+}
+
+func emailInDomains(email string, domains []string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+
+	emailDomain := strings.ToLower(email[at+1:])
+	for _, domain := range domains {
+		if emailDomain == domain {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (s *TeamService) LeaveTeam(ctx context.Context, teamID, userID int64) error {
@@ -246,43 +924,55 @@ func (s *TeamService) LeaveTeam(ctx context.Context, teamID, userID int64) error
 		return err
 	}
 
-	return nil
+	return s.audit(ctx, s.db, teamID, userID, models.AuditMemberLeft, "member", userID, nil)
 }
 
+// DeleteTeam moves the team and its subscriptions, channels and their links
+// to the trash in one transaction. Memberships are kept so a restore brings
+// the team back as it was.
 func (s *TeamService) DeleteTeam(ctx context.Context, teamID, userID int64) error {
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		deletedAt := trashTime()
 
-	membership := new(models.TeamMembership)
-	err := s.db.NewSelect().
-		Model(membership).
-		Where("team_id = ?", teamID).
-		Where("user_id = ?", userID).
-		Scan(ctx)
-
-	if err != nil {
-		return errors.New("you are not a member of this team")
-	}
+		subscriptionIDs := tx.NewSelect().Model((*models.Subscription)(nil)).Column("id").Where("team_id = ?", teamID)
+		channelIDs := tx.NewSelect().Model((*models.Channel)(nil)).Column("id").Where("team_id = ?", teamID)
 
-	if membership.Role != models.TeamRoleOwner {
-		return errors.New("only the team owner can delete the team")
-	}
+		err := moveToTrash(ctx, tx, (*models.SubscriptionChannel)(nil), deletedAt,
+			"subscription_id IN (?) OR channel_id IN (?)", subscriptionIDs, channelIDs)
+		if err != nil {
+			return err
+		}
 
-	_, err = s.db.NewDelete().
-		Model((*models.TeamMembership)(nil)).
-		Where("team_id = ?", teamID).
-		Exec(ctx)
+		for _, model := range []interface{}{(*models.Subscription)(nil), (*models.Channel)(nil)} {
+			if err := moveToTrash(ctx, tx, model, deletedAt, "team_id = ?", teamID); err != nil {
+				return err
+			}
+		}
 
-	if err != nil {
-		return err
-	}
+		if err := moveToTrash(ctx, tx, (*models.Team)(nil), deletedAt, "id = ?", teamID); err != nil {
+			return err
+		}
 
-	_, err = s.db.NewDelete().
-		Model((*models.Team)(nil)).
-		Where("id = ?", teamID).
-		Exec(ctx)
+		return s.audit(ctx, tx, teamID, userID, models.AuditTeamDeleted, "team", teamID, nil)
+	})
+}
 
+func (s *TeamService) GetTrashedByID(ctx context.Context, id int64) (*models.Team, error) {
+	team := new(models.Team)
+	err := s.db.NewSelect().Model(team).Where("id = ?", id).WhereDeleted().Scan(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return team, nil
+}
 
-	return nil
+func (s *TeamService) audit(ctx context.Context, db bun.IDB, teamID, actorID int64, action models.AuditAction, resourceType string, resourceID int64, changes map[string]models.AuditChange) error {
+	return s.auditService.Record(ctx, db, AuditEntry{
+		TeamID:       &teamID,
+		ActorID:      actorID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Changes:      changes,
+	})
 }
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/uptrace/bun"
+
+	"github.com/open-move/intercord/internal/authz"
+	"github.com/open-move/intercord/internal/models"
+)
+
+type ImportResourcesInput struct {
+	SubscriptionIDs []int64 `json:"subscription_ids"`
+	ChannelIDs      []int64 `json:"channel_ids"`
+}
+
+type ImportResourcesResult struct {
+	Subscriptions []models.Subscription `json:"subscriptions"`
+	Channels      []models.Channel      `json:"channels"`
+}
+
+// ImportResources moves personal subscriptions and channels of the caller into
+// a team. Rows keep their IDs, so channel links and notification history stay
+// attached to them.
+func (s *TeamService) ImportResources(ctx context.Context, teamID, userID int64, input ImportResourcesInput) (*ImportResourcesResult, error) {
+	subscriptionIDs := uniqueIDs(input.SubscriptionIDs)
+	channelIDs := uniqueIDs(input.ChannelIDs)
+
+	if len(subscriptionIDs) == 0 && len(channelIDs) == 0 {
+		return nil, errors.New("nothing to move: provide subscription_ids or channel_ids")
+	}
+
+	membership, err := s.GetMembership(ctx, teamID, userID)
+	if err != nil {
+		return nil, errors.New("you are not a member of this team")
+	}
+
+	if len(subscriptionIDs) > 0 && !authz.Allows(membership, authz.SubscriptionCreate) {
+		return nil, authz.ErrForbidden
+	}
+
+	if len(channelIDs) > 0 && !authz.Allows(membership, authz.ChannelCreate) {
+		return nil, authz.ErrForbidden
+	}
+
+	result := &ImportResourcesResult{
+		Subscriptions: []models.Subscription{},
+		Channels:      []models.Channel{},
+	}
+
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if len(subscriptionIDs) > 0 {
+			err := tx.NewSelect().
+				Model(&result.Subscriptions).
+				Where("id IN (?)", bun.In(subscriptionIDs)).
+				Where("team_id IS NULL").
+				Where("user_id = ?", userID).
+				For("UPDATE").
+				Scan(ctx)
+
+			if err != nil {
+				return err
+			}
+
+			if len(result.Subscriptions) != len(subscriptionIDs) {
+				return errors.New("only your own personal subscriptions can be moved into a team")
+			}
+		}
+
+		if len(channelIDs) > 0 {
+			err := tx.NewSelect().
+				Model(&result.Channels).
+				Where("id IN (?)", bun.In(channelIDs)).
+				Where("team_id IS NULL").
+				Where("user_id = ?", userID).
+				For("UPDATE").
+				Scan(ctx)
+
+			if err != nil {
+				return err
+			}
+
+			if len(result.Channels) != len(channelIDs) {
+				return errors.New("only your own personal channels can be moved into a team")
+			}
+		}
+
+		if err := checkImportLinks(ctx, tx, teamID, subscriptionIDs, channelIDs); err != nil {
+			return err
+		}
+
+		if err := s.quotaService.CheckLimit(ctx, tx, teamID, QuotaSubscriptions, len(subscriptionIDs)); err != nil {
+			return err
+		}
+
+		if err := s.quotaService.CheckLimit(ctx, tx, teamID, QuotaChannels, len(channelIDs)); err != nil {
+			return err
+		}
+
+		for i := range result.Subscriptions {
+			subscription := &result.Subscriptions[i]
+			subscription.TeamID = &teamID
+
+			_, err := tx.NewUpdate().Model(subscription).Column("team_id").Where("id = ?", subscription.ID).Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			err = s.audit(ctx, tx, teamID, userID, models.AuditSubscriptionImported, "subscription", subscription.ID, map[string]models.AuditChange{
+				"team_id": {To: teamID},
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		for i := range result.Channels {
+			channel := &result.Channels[i]
+			channel.TeamID = &teamID
+
+			_, err := tx.NewUpdate().Model(channel).Column("team_id").Where("id = ?", channel.ID).Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			err = s.audit(ctx, tx, teamID, userID, models.AuditChannelImported, "channel", channel.ID, map[string]models.AuditChange{
+				"team_id": {To: teamID},
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// checkImportLinks keeps the rule from SubscribeToSubscription: a linked
+// subscription and channel that both belong to teams must share the team.
+func checkImportLinks(ctx context.Context, tx bun.Tx, teamID int64, subscriptionIDs, channelIDs []int64) error {
+	if len(subscriptionIDs) > 0 {
+		var channels []models.Channel
+		err := tx.NewSelect().
+			Model(&channels).
+			Where("c.id IN (?)", tx.NewSelect().
+				Model((*models.SubscriptionChannel)(nil)).
+				Column("channel_id").
+				Where("subscription_id IN (?)", bun.In(subscriptionIDs))).
+			Where("c.team_id IS NOT NULL").
+			Where("c.team_id != ?", teamID).
+			Limit(1).
+			Scan(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		if len(channels) > 0 {
+			return fmt.Errorf("a subscription is linked to channel %d of another team; unlink it first", channels[0].ID)
+		}
+	}
+
+	if len(channelIDs) > 0 {
+		var subscriptions []models.Subscription
+		err := tx.NewSelect().
+			Model(&subscriptions).
+			Where("s.id IN (?)", tx.NewSelect().
+				Model((*models.SubscriptionChannel)(nil)).
+				Column("subscription_id").
+				Where("channel_id IN (?)", bun.In(channelIDs))).
+			Where("s.team_id IS NOT NULL").
+			Where("s.team_id != ?", teamID).
+			Limit(1).
+			Scan(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		if len(subscriptions) > 0 {
+			return fmt.Errorf("a channel is linked to subscription %d of another team; unlink it first", subscriptions[0].ID)
+		}
+	}
+
+	return nil
+}
+
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	unique := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
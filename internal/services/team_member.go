@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/open-move/intercord/internal/authz"
+	"github.com/open-move/intercord/internal/models"
+)
+
+type UpdateMemberRoleInput struct {
+	Role   string `json:"role" binding:"omitempty,oneof=admin member"`
+	RoleID *int64 `json:"role_id"`
+}
+
+type TransferOwnershipInput struct {
+	UserID int64 `json:"user_id" binding:"required"`
+}
+
+func (s *TeamService) GetMembers(ctx context.Context, teamID int64) ([]models.TeamMembership, error) {
+	var members []models.TeamMembership
+	err := s.db.NewSelect().
+		Model(&members).
+		Relation("User").
+		Relation("CustomRole").
+		Where("tm.team_id = ?", teamID).
+		Order("tm.created_at ASC").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (s *TeamService) UpdateMemberRole(ctx context.Context, teamID, memberID, userID int64, input UpdateMemberRoleInput) (*models.TeamMembership, error) {
+	if (input.Role == "") == (input.RoleID == nil) {
+		return nil, errors.New("provide either a built-in role or a custom role_id")
+	}
+
+	actor, target, err := s.loadMemberPair(ctx, teamID, userID, memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.Role == models.TeamRoleOwner {
+		return nil, errors.New("the owner's role can only change by transferring ownership")
+	}
+
+	if !outranks(actor, target) {
+		return nil, errors.New("you can only change the role of members with fewer permissions than you")
+	}
+
+	previousRole := roleLabel(target)
+	target.Role = models.TeamRoleMember
+	target.CustomRoleID = nil
+	target.CustomRole = nil
+
+	if input.RoleID != nil {
+		role, err := s.getRole(ctx, teamID, *input.RoleID)
+		if err != nil {
+			return nil, err
+		}
+		target.CustomRoleID = &role.ID
+		target.CustomRole = role
+	} else {
+		target.Role = models.TeamRole(input.Role)
+	}
+
+	if !authz.Covers(authz.Permissions(actor), authz.Permissions(target)) {
+		return nil, errors.New("you cannot grant permissions you don't have")
+	}
+
+	target.UpdatedAt = time.Now()
+
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().
+			Model(target).
+			Column("role", "custom_role_id", "updated_at").
+			Where("id = ?", target.ID).
+			Exec(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, tx, teamID, userID, models.AuditMemberRoleChanged, "member", target.UserID, map[string]models.AuditChange{
+			"role": {From: previousRole, To: roleLabel(target)},
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return target, nil
+}
+
+func (s *TeamService) RemoveMember(ctx context.Context, teamID, memberID, userID int64) error {
+	if memberID == userID {
+		return errors.New("use leave to remove yourself from a team")
+	}
+
+	actor, target, err := s.loadMemberPair(ctx, teamID, userID, memberID)
+	if err != nil {
+		return err
+	}
+
+	if target.Role == models.TeamRoleOwner {
+		return errors.New("the team owner cannot be removed")
+	}
+
+	if !outranks(actor, target) {
+		return errors.New("you can only remove members with fewer permissions than you")
+	}
+
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewDelete().
+			Model(target).
+			Where("id = ?", target.ID).
+			Exec(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, tx, teamID, userID, models.AuditMemberRemoved, "member", target.UserID, map[string]models.AuditChange{
+			"role": {From: roleLabel(target)},
+		})
+	})
+}
+
+func (s *TeamService) TransferOwnership(ctx context.Context, teamID, userID int64, input TransferOwnershipInput) (*models.Team, error) {
+	if input.UserID == userID {
+		return nil, errors.New("you already own this team")
+	}
+
+	actor, target, err := s.loadMemberPair(ctx, teamID, userID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	team := new(models.Team)
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		now := time.Now()
+
+		err := tx.NewSelect().Model(team).Where("id = ?", teamID).For("UPDATE").Scan(ctx)
+		if err != nil {
+			return errors.New("team not found")
+		}
+
+		if team.OwnerID != userID {
+			return errors.New("only the team owner can transfer ownership")
+		}
+
+		team.OwnerID = target.UserID
+		team.UpdatedAt = now
+
+		_, err = tx.NewUpdate().
+			Model(team).
+			Column("owner_id", "updated_at").
+			Where("id = ?", teamID).
+			Exec(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*models.TeamMembership)(nil)).
+			Set("role = ?", models.TeamRoleOwner).
+			Set("custom_role_id = NULL").
+			Set("updated_at = ?", now).
+			Where("id = ?", target.ID).
+			Exec(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*models.TeamMembership)(nil)).
+			Set("role = ?", models.TeamRoleAdmin).
+			Set("updated_at = ?", now).
+			Where("id = ?", actor.ID).
+			Exec(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, tx, teamID, userID, models.AuditTeamOwnershipTransferred, "team", teamID, map[string]models.AuditChange{
+			"owner_id": {From: userID, To: target.UserID},
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+func (s *TeamService) loadMemberPair(ctx context.Context, teamID, actorID, targetID int64) (*models.TeamMembership, *models.TeamMembership, error) {
+	actor, err := s.GetMembership(ctx, teamID, actorID)
+	if err != nil {
+		return nil, nil, errors.New("you are not a member of this team")
+	}
+
+	target, err := s.GetMembership(ctx, teamID, targetID)
+	if err != nil {
+		return nil, nil, errors.New("user is not a member of this team")
+	}
+
+	return actor, target, nil
+}
+
+// checkGrant makes sure a member may hand out a role, so nobody can invite
+// others, or a second account of their own, with permissions they lack.
+func (s *TeamService) checkGrant(ctx context.Context, teamID, granterID int64, role models.TeamRole) error {
+	granter, err := s.GetMembership(ctx, teamID, granterID)
+	if err != nil {
+		return errors.New("you are not a member of this team")
+	}
+
+	if !authz.CanGrant(granter, role) {
+		return errors.New("you cannot grant permissions you don't have")
+	}
+
+	return nil
+}
+
+// outranks reports whether the actor holds every permission of the target and
+// at least one more, so nobody can manage peers or escalate through them.
+func outranks(actor, target *models.TeamMembership) bool {
+	actorPermissions := authz.Permissions(actor)
+	targetPermissions := authz.Permissions(target)
+	return authz.Covers(actorPermissions, targetPermissions) && !authz.Covers(targetPermissions, actorPermissions)
+}
+
+// roleLabel names a member's effective role for the audit log.
+func roleLabel(membership *models.TeamMembership) string {
+	if membership.CustomRole != nil {
+		return membership.CustomRole.Name
+	}
+	return string(membership.Role)
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/open-move/intercord/internal/authz"
+	"github.com/open-move/intercord/internal/models"
+)
+
+type TeamRoleInput struct {
+	Name        string   `json:"name" binding:"required,max=64"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions" binding:"required,min=1"`
+}
+
+func (s *TeamService) GetRoles(ctx context.Context, teamID int64) ([]models.TeamRoleDefinition, error) {
+	var roles []models.TeamRoleDefinition
+	err := s.db.NewSelect().
+		Model(&roles).
+		Where("team_id = ?", teamID).
+		Order("name ASC").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (s *TeamService) CreateRole(ctx context.Context, teamID, userID int64, input TeamRoleInput) (*models.TeamRoleDefinition, error) {
+	name, permissions, err := s.validateRole(ctx, teamID, userID, 0, input)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.TeamRoleDefinition{
+		TeamID:      teamID,
+		Name:        name,
+		Description: input.Description,
+		Permissions: permissions,
+	}
+
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(role).Exec(ctx); err != nil {
+			return err
+		}
+
+		return s.audit(ctx, tx, teamID, userID, models.AuditRoleCreated, "role", role.ID, auditDiff(nil, roleAuditFields(role)))
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (s *TeamService) UpdateRole(ctx context.Context, teamID, roleID, userID int64, input TeamRoleInput) (*models.TeamRoleDefinition, error) {
+	role, err := s.getRole(ctx, teamID, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	actor, err := s.GetMembership(ctx, teamID, userID)
+	if err != nil {
+		return nil, errors.New("you are not a member of this team")
+	}
+
+	current, err := authz.ParseActions(role.Permissions)
+	if err != nil || !authz.Covers(authz.Permissions(actor), current) {
+		return nil, errors.New("you cannot edit a role with permissions you don't have")
+	}
+
+	name, permissions, err := s.validateRole(ctx, teamID, userID, roleID, input)
+	if err != nil {
+		return nil, err
+	}
+
+	before := roleAuditFields(role)
+	role.Name = name
+	role.Description = input.Description
+	role.Permissions = permissions
+	role.UpdatedAt = time.Now()
+
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().
+			Model(role).
+			Column("name", "description", "permissions", "updated_at").
+			Where("id = ?", role.ID).
+			Exec(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		changes := auditDiff(before, roleAuditFields(role))
+		if len(changes) == 0 {
+			return nil
+		}
+
+		return s.audit(ctx, tx, teamID, userID, models.AuditRoleUpdated, "role", role.ID, changes)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (s *TeamService) DeleteRole(ctx context.Context, teamID, roleID, userID int64) error {
+	role, err := s.getRole(ctx, teamID, roleID)
+	if err != nil {
+		return err
+	}
+
+	assigned, err := s.db.NewSelect().
+		Model((*models.TeamMembership)(nil)).
+		Where("custom_role_id = ?", role.ID).
+		Count(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if assigned > 0 {
+		return fmt.Errorf("role is still assigned to %d member(s)", assigned)
+	}
+
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewDelete().
+			Model(role).
+			Where("id = ?", role.ID).
+			Exec(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, tx, teamID, userID, models.AuditRoleDeleted, "role", role.ID, auditDiff(roleAuditFields(role), nil))
+	})
+}
+
+func (s *TeamService) getRole(ctx context.Context, teamID, roleID int64) (*models.TeamRoleDefinition, error) {
+	role := new(models.TeamRoleDefinition)
+	err := s.db.NewSelect().
+		Model(role).
+		Where("id = ?", roleID).
+		Where("team_id = ?", teamID).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, errors.New("role not found")
+	}
+
+	return role, nil
+}
+
+func (s *TeamService) validateRole(ctx context.Context, teamID, userID, roleID int64, input TeamRoleInput) (string, []string, error) {
+	name := strings.TrimSpace(input.Name)
+	if _, builtIn := authz.BuiltInRoles()[models.TeamRole(strings.ToLower(name))]; builtIn || name == "" {
+		return "", nil, errors.New("role name is reserved or empty")
+	}
+
+	actions, err := authz.ParseActions(input.Permissions)
+	if err != nil {
+		return "", nil, err
+	}
+
+	actor, err := s.GetMembership(ctx, teamID, userID)
+	if err != nil {
+		return "", nil, errors.New("you are not a member of this team")
+	}
+
+	if !authz.Covers(authz.Permissions(actor), actions) {
+		return "", nil, errors.New("you cannot grant permissions you don't have")
+	}
+
+	taken, err := s.db.NewSelect().
+		Model((*models.TeamRoleDefinition)(nil)).
+		Where("team_id = ?", teamID).
+		Where("LOWER(name) = LOWER(?)", name).
+		Where("id != ?", roleID).
+		Exists(ctx)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	if taken {
+		return "", nil, errors.New("a role with this name already exists")
+	}
+
+	permissions := make([]string, len(actions))
+	for i, action := range actions {
+		permissions[i] = string(action)
+	}
+
+	return name, permissions, nil
+}
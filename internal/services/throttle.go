@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/open-move/intercord/internal/config"
+	"github.com/open-move/intercord/internal/models"
+)
+
+type ThrottleError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *ThrottleError) Error() string {
+	if e.Locked {
+		return "too many failed attempts; this account is temporarily locked"
+	}
+	return fmt.Sprintf("too many attempts; retry in %d seconds", int(e.RetryAfter.Seconds()+0.5))
+}
+
+type AuthThrottle struct {
+	store  AttemptStore
+	config *config.ThrottleConfig
+}
+
+func NewAuthThrottle(store AttemptStore, config *config.ThrottleConfig) *AuthThrottle {
+	return &AuthThrottle{
+		store:  store,
+		config: config,
+	}
+}
+
+func ThrottleKey(scope, value string) string {
+	return scope + ":" + strings.ToLower(strings.TrimSpace(value))
+}
+
+func (t *AuthThrottle) Check(ctx context.Context, keys ...string) error {
+	now := time.Now()
+
+	for _, key := range keys {
+		state, err := t.store.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		if state.LockedUntil.After(now) {
+			return &ThrottleError{RetryAfter: state.LockedUntil.Sub(now), Locked: true}
+		}
+
+		if state.WindowStart.Before(now.Add(-t.config.Window)) {
+			continue
+		}
+
+		nextAllowed := state.LastFailure.Add(t.delay(state.Failures))
+		if nextAllowed.After(now) {
+			return &ThrottleError{RetryAfter: nextAllowed.Sub(now)}
+		}
+	}
+
+	return nil
+}
+
+func (t *AuthThrottle) Failure(ctx context.Context, key string, limit int) (time.Time, error) {
+	state, err := t.store.RecordFailure(ctx, key, t.config.Window)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if limit <= 0 || state.Failures < limit {
+		return time.Time{}, nil
+	}
+
+	lockedUntil := time.Now().Add(t.config.LockoutDuration)
+	if err := t.store.Lock(ctx, key, lockedUntil); err != nil {
+		return time.Time{}, err
+	}
+
+	return lockedUntil, nil
+}
+
+func (t *AuthThrottle) Reset(ctx context.Context, key string) error {
+	return t.store.Reset(ctx, key)
+}
+
+func (t *AuthThrottle) Record(ctx context.Context, attempt *models.AuthAttempt) error {
+	return t.store.LogAttempt(ctx, attempt)
+}
+
+func (t *AuthThrottle) delay(failures int) time.Duration {
+	excess := failures - t.config.FreeAttempts
+	if excess <= 0 {
+		return 0
+	}
+
+	delay := t.config.BaseDelay
+	for i := 1; i < excess && delay < t.config.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > t.config.MaxDelay {
+		return t.config.MaxDelay
+	}
+
+	return delay
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/open-move/intercord/internal/models"
+	"github.com/open-move/intercord/internal/sui"
+)
+
+// transactionStream reads the transactions matching one node filter.
+// Subscriptions watching the same thing share a stream, and so its cursor and
+// RPC calls.
+type transactionStream struct {
+	name          string
+	filter        sui.TransactionFilter
+	subscriptions []*models.Subscription
+	payloads      streamPayloads
+
+	// since is when the stream's oldest subscription was created, which is
+	// as far back as the stream reads when it has no cursor yet.
+	since time.Time
+
+	// save, when set, stores the state the stream's subscriptions reached in
+	// a page, along with the page's notifications.
+	save func(ctx context.Context, tx bun.Tx, subscriptions []*models.Subscription) error
+}
+
+// streamPayloads returns the notifications a transaction produces for one of
+// a stream's subscriptions.
+type streamPayloads func(ctx context.Context, block sui.TransactionBlock, subscription *models.Subscription) ([]interface{}, error)
+
+type transactionStreams map[string]*transactionStream
+
+func (streams transactionStreams) add(name string, filter sui.TransactionFilter, subscription *models.Subscription, payloads streamPayloads) *transactionStream {
+	stream, ok := streams[name]
+	if !ok {
+		stream = &transactionStream{name: name, filter: filter, payloads: payloads, since: subscription.CreatedAt}
+		streams[name] = stream
+	}
+	if subscription.CreatedAt.Before(stream.since) {
+		stream.since = subscription.CreatedAt
+	}
+	stream.subscriptions = append(stream.subscriptions, subscription)
+	return stream
+}
+
+func (streams transactionStreams) sorted() []*transactionStream {
+	names := make([]string, 0, len(streams))
+	for name := range streams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ordered := make([]*transactionStream, 0, len(names))
+	for _, name := range names {
+		ordered = append(ordered, streams[name])
+	}
+	return ordered
+}
+
+// PollTransactions reads the transactions watched by active address, object,
+// balance and package subscriptions and queues their notifications.
+func (s *IngestionService) PollTransactions(ctx context.Context) error {
+	subscriptions, err := s.activeSubscriptions(ctx, models.SubscriptionKindAddress, models.SubscriptionKindObject, models.SubscriptionKindBalance, models.SubscriptionKindPackage)
+	if err != nil || len(subscriptions) == 0 {
+		return err
+	}
+
+	ids := make([]int64, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		ids = append(ids, subscription.ID)
+	}
+
+	channels, err := s.subscriptionChannels(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	streams := make(transactionStreams)
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		switch subscription.Kind {
+		case models.SubscriptionKindAddress:
+			addAddressStreams(streams, subscription)
+		case models.SubscriptionKindObject:
+			s.addObjectStreams(streams, subscription)
+		case models.SubscriptionKindBalance:
+			addBalanceStreams(streams, subscription)
+		case models.SubscriptionKindPackage:
+			s.addPackageStreams(streams, subscription)
+		}
+	}
+
+	for _, stream := range streams.sorted() {
+		if err := s.pollStream(ctx, stream, channels); err != nil {
+			log.Printf("Polling %s failed: %v", stream.name, err)
+		}
+	}
+
+	s.reconcileBalances(ctx, subscriptions, channels)
+	return nil
+}
+
+func (s *IngestionService) pollStream(ctx context.Context, stream *transactionStream, channels map[int64][]int64) error {
+	from, err := s.cursor(ctx, stream.name)
+	if err != nil {
+		return err
+	}
+
+	after := from
+	if from == nil {
+		if after, err = s.startingTransaction(ctx, stream); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < s.config.PollMaxPages; i++ {
+		var cursor *string
+		if after != nil {
+			cursor = &after.TxDigest
+		}
+
+		page, err := s.client.QueryTransactionBlocks(ctx, stream.filter, cursor, s.config.PollPageSize, false)
+		if err != nil || len(page.Data) == 0 {
+			return err
+		}
+
+		next := page.Data[len(page.Data)-1].Digest
+		if page.NextCursor != nil {
+			next = *page.NextCursor
+		}
+
+		to := &models.IngestionCursor{TxDigest: next}
+		if err := s.ingestTransactions(ctx, stream, channels, page.Data, from, to); err != nil {
+			return ignoreCursorMoved(err)
+		}
+
+		if !page.HasNextPage {
+			return nil
+		}
+		from, after = to, to
+	}
+
+	return nil
+}
+
+// startingTransaction looks back from the newest matching transaction for the
+// last one executed before the stream's since, which a new stream reads after.
+// It works like startingEvent.
+func (s *IngestionService) startingTransaction(ctx context.Context, stream *transactionStream) (*models.IngestionCursor, error) {
+	var cursor *string
+	for i := 0; i < s.config.PollMaxPages; i++ {
+		page, err := s.client.QueryTransactionBlocks(ctx, stream.filter, cursor, s.config.PollPageSize, true)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, block := range page.Data {
+			if executed := block.Timestamp(); !executed.IsZero() && executed.Before(stream.since) {
+				return &models.IngestionCursor{TxDigest: block.Digest}, nil
+			}
+		}
+
+		if !page.HasNextPage || len(page.Data) == 0 {
+			return nil, nil
+		}
+		cursor = &page.Data[len(page.Data)-1].Digest
+	}
+
+	log.Printf("Starting %s %d pages back, skipping its earlier transactions since %s", stream.name, s.config.PollMaxPages, stream.since.Format(time.RFC3339))
+	return &models.IngestionCursor{TxDigest: *cursor}, nil
+}
+
+type queuedPayload struct {
+	subscription *models.Subscription
+	payload      []byte
+}
+
+// ingestTransactions builds the payloads of a page before opening the database
+// transaction, since building them may call the node.
+func (s *IngestionService) ingestTransactions(ctx context.Context, stream *transactionStream, channels map[int64][]int64, blocks []sui.TransactionBlock, from, to *models.IngestionCursor) (err error) {
+	if stream.save != nil {
+		// Building payloads updates the subscriptions' state. Other streams
+		// of the same subscriptions mustn't build on state that was never
+		// saved, so it is put back if the page isn't.
+		saved := make([]models.Subscription, len(stream.subscriptions))
+		for i, subscription := range stream.subscriptions {
+			saved[i] = *subscription
+		}
+
+		defer func() {
+			if err != nil {
+				for i, subscription := range stream.subscriptions {
+					*subscription = saved[i]
+				}
+			}
+		}()
+	}
+
+	var queued []queuedPayload
+	for _, block := range blocks {
+		for _, subscription := range stream.subscriptions {
+			if executed := block.Timestamp(); !executed.IsZero() && executed.Before(subscription.CreatedAt) {
+				continue
+			}
+
+			payloads, err := stream.payloads(ctx, block, subscription)
+			if err != nil {
+				return err
+			}
+
+			for _, payload := range payloads {
+				encoded, err := json.Marshal(payload)
+				if err != nil {
+					return err
+				}
+				queued = append(queued, queuedPayload{subscription: subscription, payload: encoded})
+			}
+		}
+	}
+
+	return s.commit(ctx, stream.name, from, to, func(queue *notificationQueue) error {
+		for _, q := range queued {
+			if err := queue.add(q.subscription, channels[q.subscription.ID], q.payload); err != nil {
+				return err
+			}
+		}
+
+		if stream.save != nil {
+			return stream.save(ctx, queue.tx, stream.subscriptions)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/open-move/intercord/internal/config"
+	"github.com/open-move/intercord/internal/models"
+)
+
+type TrashService struct {
+	db           *bun.DB
+	config       *config.TrashConfig
+	auditService *AuditService
+	quotaService *QuotaService
+}
+
+func NewTrashService(db *bun.DB, config *config.TrashConfig, auditService *AuditService, quotaService *QuotaService) *TrashService {
+	return &TrashService{
+		db:           db,
+		config:       config,
+		auditService: auditService,
+		quotaService: quotaService,
+	}
+}
+
+type TrashItem struct {
+	Type      string    `json:"type"`
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	TeamID    *int64    `json:"team_id,omitempty"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+// GetUserTrash lists the caller's deleted teams and personal subscriptions and
+// channels that can still be restored.
+func (s *TrashService) GetUserTrash(ctx context.Context, userID int64) ([]TrashItem, error) {
+	var teams []models.Team
+	err := s.db.NewSelect().
+		Model(&teams).
+		Where("owner_id = ?", userID).
+		WhereDeleted().
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]TrashItem, 0, len(teams))
+	for _, team := range teams {
+		items = append(items, s.item("team", team.ID, team.Name, nil, team.DeletedAt))
+	}
+
+	resources, err := s.trashedResources(ctx, "team_id IS NULL AND user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sorted(append(items, resources...)), nil
+}
+
+// GetTeamTrash lists subscriptions and channels deleted from a live team.
+// Resources deleted together with their team only come back with the team.
+func (s *TrashService) GetTeamTrash(ctx context.Context, teamID int64) ([]TrashItem, error) {
+	items, err := s.trashedResources(ctx, "team_id = ?", teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sorted(items), nil
+}
+
+func (s *TrashService) RestoreTeam(ctx context.Context, teamID, userID int64) (*models.Team, error) {
+	team := new(models.Team)
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		err := tx.NewSelect().Model(team).Where("id = ?", teamID).WhereDeleted().For("UPDATE").Scan(ctx)
+		if err != nil {
+			return errors.New("team not found in trash")
+		}
+
+		if err := s.checkGracePeriod(team.DeletedAt); err != nil {
+			return err
+		}
+
+		deletedAt := team.DeletedAt
+		for _, model := range []interface{}{(*models.Subscription)(nil), (*models.Channel)(nil)} {
+			if err := restoreFromTrash(ctx, tx, model, deletedAt, "team_id = ?", teamID); err != nil {
+				return err
+			}
+		}
+
+		err = restoreFromTrash(ctx, tx, (*models.SubscriptionChannel)(nil), deletedAt,
+			"subscription_id IN (?)", tx.NewSelect().Model((*models.Subscription)(nil)).Column("id").Where("team_id = ?", teamID))
+		if err != nil {
+			return err
+		}
+
+		if err := restoreFromTrash(ctx, tx, (*models.Team)(nil), deletedAt, "id = ?", teamID); err != nil {
+			return err
+		}
+
+		team.DeletedAt = time.Time{}
+		return s.auditService.Record(ctx, tx, AuditEntry{
+			TeamID:       &team.ID,
+			ActorID:      userID,
+			Action:       models.AuditTeamRestored,
+			ResourceType: "team",
+			ResourceID:   team.ID,
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+func (s *TrashService) RestoreSubscription(ctx context.Context, id, userID int64) (*models.Subscription, error) {
+	subscription := new(models.Subscription)
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		err := tx.NewSelect().Model(subscription).Where("id = ?", id).WhereDeleted().For("UPDATE").Scan(ctx)
+		if err != nil {
+			return errors.New("subscription not found in trash")
+		}
+
+		if err := s.checkRestorable(ctx, tx, subscription.TeamID, QuotaSubscriptions, subscription.DeletedAt); err != nil {
+			return err
+		}
+
+		deletedAt := subscription.DeletedAt
+		if err := restoreFromTrash(ctx, tx, (*models.Subscription)(nil), deletedAt, "id = ?", id); err != nil {
+			return err
+		}
+
+		err = restoreFromTrash(ctx, tx, (*models.SubscriptionChannel)(nil), deletedAt,
+			"subscription_id = ? AND channel_id IN (?)", id, tx.NewSelect().Model((*models.Channel)(nil)).Column("id"))
+		if err != nil {
+			return err
+		}
+
+		subscription.DeletedAt = time.Time{}
+		return s.auditService.Record(ctx, tx, AuditEntry{
+			TeamID:       subscription.TeamID,
+			ActorID:      userID,
+			Action:       models.AuditSubscriptionRestored,
+			ResourceType: "subscription",
+			ResourceID:   subscription.ID,
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (s *TrashService) RestoreChannel(ctx context.Context, id, userID int64) (*models.Channel, error) {
+	channel := new(models.Channel)
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		err := tx.NewSelect().Model(channel).Where("id = ?", id).WhereDeleted().For("UPDATE").Scan(ctx)
+		if err != nil {
+			return errors.New("channel not found in trash")
+		}
+
+		if err := s.checkRestorable(ctx, tx, channel.TeamID, QuotaChannels, channel.DeletedAt); err != nil {
+			return err
+		}
+
+		deletedAt := channel.DeletedAt
+		if err := restoreFromTrash(ctx, tx, (*models.Channel)(nil), deletedAt, "id = ?", id); err != nil {
+			return err
+		}
+
+		err = restoreFromTrash(ctx, tx, (*models.SubscriptionChannel)(nil), deletedAt,
+			"channel_id = ? AND subscription_id IN (?)", id, tx.NewSelect().Model((*models.Subscription)(nil)).Column("id"))
+		if err != nil {
+			return err
+		}
+
+		channel.DeletedAt = time.Time{}
+		return s.auditService.Record(ctx, tx, AuditEntry{
+			TeamID:       channel.TeamID,
+			ActorID:      userID,
+			Action:       models.AuditChannelRestored,
+			ResourceType: "channel",
+			ResourceID:   channel.ID,
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return channel, nil
+}
+
+// Purge permanently removes everything that has been in the trash for longer
+// than the grace period, along with the notifications that reference it.
+func (s *TrashService) Purge(ctx context.Context) error {
+	cutoff := time.Now().Add(-s.config.Retention)
+
+	var teamIDs []int64
+	err := s.db.NewSelect().
+		Model((*models.Team)(nil)).
+		Column("id").
+		WhereDeleted().
+		Where("deleted_at < ?", cutoff).
+		Scan(ctx, &teamIDs)
+
+	if err != nil {
+		return err
+	}
+
+	for _, teamID := range teamIDs {
+		err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			return purgeTeam(ctx, tx, teamID)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		err := purgeResources(ctx, tx, "deleted_at != ? AND deleted_at < ?", time.Time{}, cutoff)
+		if err != nil {
+			return err
+		}
+
+		for _, model := range []interface{}{(*models.SubscriptionChannel)(nil), (*models.TeamMembership)(nil)} {
+			_, err := tx.NewDelete().
+				Model(model).
+				WhereDeleted().
+				Where("deleted_at < ?", cutoff).
+				ForceDelete().
+				Exec(ctx)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *TrashService) trashedResources(ctx context.Context, where string, args ...interface{}) ([]TrashItem, error) {
+	var subscriptions []models.Subscription
+	err := s.db.NewSelect().Model(&subscriptions).Where(where, args...).WhereDeleted().Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var channels []models.Channel
+	err = s.db.NewSelect().Model(&channels).Where(where, args...).WhereDeleted().Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]TrashItem, 0, len(subscriptions)+len(channels))
+	for _, subscription := range subscriptions {
+		items = append(items, s.item("subscription", subscription.ID, subscription.Name, subscription.TeamID, subscription.DeletedAt))
+	}
+	for _, channel := range channels {
+		items = append(items, s.item("channel", channel.ID, channel.Name, channel.TeamID, channel.DeletedAt))
+	}
+
+	return items, nil
+}
+
+func (s *TrashService) item(kind string, id int64, name string, teamID *int64, deletedAt time.Time) TrashItem {
+	return TrashItem{
+		Type:      kind,
+		ID:        id,
+		Name:      name,
+		TeamID:    teamID,
+		DeletedAt: deletedAt,
+		PurgeAt:   deletedAt.Add(s.config.Retention),
+	}
+}
+
+func (s *TrashService) sorted(items []TrashItem) []TrashItem {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items
+}
+
+func (s *TrashService) checkGracePeriod(deletedAt time.Time) error {
+	if time.Since(deletedAt) > s.config.Retention {
+		return errors.New("the restore grace period has ended")
+	}
+	return nil
+}
+
+// checkRestorable refuses to bring back a team resource on its own while the
+// team itself is in the trash, or when the team has no room left for it.
+func (s *TrashService) checkRestorable(ctx context.Context, tx bun.Tx, teamID *int64, resource QuotaResource, deletedAt time.Time) error {
+	if err := s.checkGracePeriod(deletedAt); err != nil {
+		return err
+	}
+
+	if teamID == nil {
+		return nil
+	}
+
+	live, err := tx.NewSelect().Model((*models.Team)(nil)).Where("id = ?", *teamID).Exists(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !live {
+		return errors.New("restore the team first")
+	}
+
+	return s.quotaService.CheckLimit(ctx, tx, *teamID, resource, 1)
+}
+
+// trashTime is the deletion timestamp shared by everything removed in one
+// operation, so a restore brings back exactly what was deleted together.
+func trashTime() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+func moveToTrash(ctx context.Context, db bun.IDB, model interface{}, deletedAt time.Time, where string, args ...interface{}) error {
+	_, err := db.NewUpdate().
+		Model(model).
+		Set("deleted_at = ?", deletedAt).
+		Where(where, args...).
+		Exec(ctx)
+
+	return err
+}
+
+func restoreFromTrash(ctx context.Context, db bun.IDB, model interface{}, deletedAt time.Time, where string, args ...interface{}) error {
+	_, err := db.NewUpdate().
+		Model(model).
+		Set("deleted_at = ?", time.Time{}).
+		WhereDeleted().
+		Where("?TableAlias.deleted_at = ?", deletedAt).
+		Where(where, args...).
+		Exec(ctx)
+
+	return err
+}
+
+func purgeTeam(ctx context.Context, tx bun.Tx, teamID int64) error {
+	if err := purgeResources(ctx, tx, "team_id = ?", teamID); err != nil {
+		return err
+	}
+
+	for _, model := range []interface{}{
+		(*models.TeamMembership)(nil),
+		(*models.TeamRoleDefinition)(nil),
+		(*models.TeamInvitation)(nil),
+		(*models.TeamJoinRequest)(nil),
+		(*models.TeamInviteLinkRedemption)(nil),
+		(*models.TeamInviteLink)(nil),
+		(*models.TeamAuditEvent)(nil),
+	} {
+		_, err := tx.NewDelete().Model(model).Where("team_id = ?", teamID).ForceDelete().Exec(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.NewDelete().Model((*models.Team)(nil)).Where("id = ?", teamID).ForceDelete().Exec(ctx)
+	return err
+}
@@ -3,6 +3,9 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/uptrace/bun"
@@ -13,16 +16,26 @@ import (
 )
 
 type UserService struct {
-	db           *bun.DB
-	jwtConfig    *config.JWTConfig
-	emailService *EmailService
+	db             *bun.DB
+	jwtConfig      *config.JWTConfig
+	authConfig     *config.AuthConfig
+	emailService   *EmailService
+	sessionService *SessionService
+	teamService    *TeamService
+	throttle       *AuthThrottle
+	throttleConf   *config.ThrottleConfig
 }
 
-func NewUserService(db *bun.DB, jwtConfig *config.JWTConfig, emailService *EmailService) *UserService {
+func NewUserService(db *bun.DB, jwtConfig *config.JWTConfig, authConfig *config.AuthConfig, emailService *EmailService, sessionService *SessionService, teamService *TeamService, throttle *AuthThrottle, throttleConf *config.ThrottleConfig) *UserService {
 	return &UserService{
-		db:           db,
-		jwtConfig:    jwtConfig,
-		emailService: emailService,
+		db:             db,
+		jwtConfig:      jwtConfig,
+		authConfig:     authConfig,
+		emailService:   emailService,
+		sessionService: sessionService,
+		teamService:    teamService,
+		throttle:       throttle,
+		throttleConf:   throttleConf,
 	}
 }
 
@@ -43,6 +56,25 @@ type ResetPasswordInput struct {
 	Password string `json:"password" binding:"required,min=8"`
 }
 
+type UpdateProfileInput struct {
+	FirstName *string `json:"first_name" binding:"omitempty,min=1"`
+	LastName  *string `json:"last_name" binding:"omitempty,min=1"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8"`
+}
+
+type ChangeEmailInput struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type DeleteAccountInput struct {
+	Password string `json:"password" binding:"required"`
+}
+
 type AuthResponse struct {
 	User         models.User `json:"user"`
 	AccessToken  string      `json:"access_token"`
@@ -75,56 +107,130 @@ func (s *UserService) Register(ctx context.Context, input RegisterUserInput, bas
 		return nil, err
 	}
 
-	token, err := utils.GenerateVerificationToken()
+	err = s.sendVerification(ctx, user, baseURL)
 	if err != nil {
 		return nil, err
 	}
 
+	// Invitations to this address are only applied once it is verified. Until
+	// then, nothing proves the registrant owns it, so they have to accept
+	// through the signed link in the invitation email.
+	return user, nil
+}
+
+func (s *UserService) ResendVerification(ctx context.Context, email, ip, baseURL string) error {
+	ipKey := ThrottleKey("ip", ip)
+	resendKey := ThrottleKey("resend", email)
+
+	if err := s.throttle.Check(ctx, ipKey, resendKey); err != nil {
+		s.recordAttempt(ctx, "resend_verification", email, ip, false, "throttled")
+		return err
+	}
+
+	if _, err := s.throttle.Failure(ctx, resendKey, s.throttleConf.MaxResendRequests); err != nil {
+		return err
+	}
+
+	user := new(models.User)
+	err := s.db.NewSelect().Model(user).Where("email = ?", email).Scan(ctx)
+	if err != nil || user.Verified {
+		return nil
+	}
+
+	_, err = s.db.NewUpdate().Model((*models.EmailVerification)(nil)).
+		Set("used = ?", true).
+		Where("user_id = ?", user.ID).
+		Where("used = ?", false).
+		Exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	return s.sendVerification(ctx, user, baseURL)
+}
+
+func (s *UserService) sendVerification(ctx context.Context, user *models.User, baseURL string) error {
+	token, err := utils.GenerateVerificationToken()
+	if err != nil {
+		return err
+	}
+
 	verification := &models.EmailVerification{
 		UserID:    user.ID,
 		Token:     token,
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		ExpiresAt: time.Now().Add(s.authConfig.VerificationTokenTTL),
 	}
 
 	_, err = s.db.NewInsert().Model(verification).Exec(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	err = s.emailService.SendVerificationEmail(user.Email, token, baseURL)
-	if err != nil {
+	return s.emailService.SendVerificationEmail(user.Email, token, baseURL)
+}
+
+func (s *UserService) Login(ctx context.Context, input LoginInput, ip, userAgent string) (*AuthResponse, error) {
+	ipKey := ThrottleKey("ip", ip)
+	accountKey := ThrottleKey("account", input.Email)
+
+	if err := s.throttle.Check(ctx, ipKey, accountKey); err != nil {
+		s.recordAttempt(ctx, "login", input.Email, ip, false, "throttled")
 		return nil, err
 	}
 
-	return user, nil
-}
-
-func (s *UserService) Login(ctx context.Context, input LoginInput) (*AuthResponse, error) {
 	user := new(models.User)
 	err := s.db.NewSelect().Model(user).Where("email = ?", input.Email).Scan(ctx)
 	if err != nil {
-		return nil, errors.New("invalid email or password")
+		return nil, s.loginFailed(ctx, input.Email, ip, nil, "unknown email")
 	}
 
 	if !utils.CheckPasswordHash(input.Password, user.Password) {
-		return nil, errors.New("invalid email or password")
+		return nil, s.loginFailed(ctx, input.Email, ip, user, "wrong password")
 	}
 
-	accessToken, err := utils.GenerateAccessToken(user.ID, s.jwtConfig)
-	if err != nil {
+	if err := s.throttle.Reset(ctx, accountKey); err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := utils.GenerateRefreshToken(user.ID, s.jwtConfig)
+	s.recordAttempt(ctx, "login", input.Email, ip, true, "")
+
+	return s.sessionService.Start(ctx, user, ip, userAgent)
+}
+
+func (s *UserService) loginFailed(ctx context.Context, email, ip string, user *models.User, reason string) error {
+	s.recordAttempt(ctx, "login", email, ip, false, reason)
+
+	if _, err := s.throttle.Failure(ctx, ThrottleKey("ip", ip), s.throttleConf.MaxIPFailures); err != nil {
+		return err
+	}
+
+	lockedUntil, err := s.throttle.Failure(ctx, ThrottleKey("account", email), s.throttleConf.MaxAccountFailures)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	if !lockedUntil.IsZero() && user != nil {
+		if err := s.emailService.SendAccountLockedEmail(user.Email, lockedUntil); err != nil {
+			log.Printf("Failed to send lockout notice to user %d: %v", user.ID, err)
+		}
 	}
 
-	return &AuthResponse{
-		User:         *user,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	}, nil
+	return errors.New("invalid email or password")
+}
+
+func (s *UserService) recordAttempt(ctx context.Context, action, email, ip string, success bool, reason string) {
+	attempt := &models.AuthAttempt{
+		Action:  action,
+		Email:   email,
+		IP:      ip,
+		Success: success,
+		Reason:  reason,
+	}
+
+	if err := s.throttle.Record(ctx, attempt); err != nil {
+		log.Printf("Failed to record %s attempt: %v", action, err)
+	}
 }
 
 func (s *UserService) VerifyEmail(ctx context.Context, token string) error {
@@ -157,16 +263,39 @@ func (s *UserService) VerifyEmail(ctx context.Context, token string) error {
 		return err
 	}
 
-	return nil
+	user, err := s.GetByID(ctx, verification.UserID)
+	if err != nil {
+		return err
+	}
+
+	return s.teamService.ApplyPendingInvitations(ctx, user)
 }
 
-func (s *UserService) RequestPasswordReset(ctx context.Context, email, baseURL string) error {
+func (s *UserService) RequestPasswordReset(ctx context.Context, email, ip, baseURL string) error {
+	ipKey := ThrottleKey("ip", ip)
+	resetKey := ThrottleKey("reset", email)
+
+	if err := s.throttle.Check(ctx, ipKey, resetKey); err != nil {
+		s.recordAttempt(ctx, "request_reset_password", email, ip, false, "throttled")
+		return err
+	}
+
+	if _, err := s.throttle.Failure(ctx, resetKey, s.throttleConf.MaxResetRequests); err != nil {
+		return err
+	}
+
 	user := new(models.User)
 	err := s.db.NewSelect().Model(user).Where("email = ?", email).Scan(ctx)
 	if err != nil {
+		s.recordAttempt(ctx, "request_reset_password", email, ip, false, "unknown email")
+		if _, err := s.throttle.Failure(ctx, ipKey, s.throttleConf.MaxIPFailures); err != nil {
+			return err
+		}
 		return nil
 	}
 
+	s.recordAttempt(ctx, "request_reset_password", email, ip, true, "")
+
 	token, err := utils.GenerateResetToken()
 	if err != nil {
 		return err
@@ -186,15 +315,26 @@ func (s *UserService) RequestPasswordReset(ctx context.Context, email, baseURL s
 	return s.emailService.SendPasswordResetEmail(user.Email, token, baseURL)
 }
 
-func (s *UserService) ResetPassword(ctx context.Context, input ResetPasswordInput) error {
+func (s *UserService) ResetPassword(ctx context.Context, input ResetPasswordInput, ip string) error {
+	ipKey := ThrottleKey("ip", ip)
+	if err := s.throttle.Check(ctx, ipKey); err != nil {
+		s.recordAttempt(ctx, "reset_password", "", ip, false, "throttled")
+		return err
+	}
+
 	reset := new(models.PasswordReset)
 	err := s.db.NewSelect().Model(reset).
+		Relation("User").
 		Where("token = ?", input.Token).
 		Where("used = ?", false).
 		Where("expires_at > ?", time.Now()).
 		Scan(ctx)
 
 	if err != nil {
+		s.recordAttempt(ctx, "reset_password", "", ip, false, "invalid token")
+		if _, err := s.throttle.Failure(ctx, ipKey, s.throttleConf.MaxIPFailures); err != nil {
+			return err
+		}
 		return errors.New("invalid or expired token")
 	}
 
@@ -205,6 +345,7 @@ func (s *UserService) ResetPassword(ctx context.Context, input ResetPasswordInpu
 
 	_, err = s.db.NewUpdate().Model(&models.User{}).
 		Set("password = ?", hashedPassword).
+		Set("updated_at = ?", time.Now()).
 		Where("id = ?", reset.UserID).
 		Exec(ctx)
 
@@ -212,6 +353,10 @@ func (s *UserService) ResetPassword(ctx context.Context, input ResetPasswordInpu
 		return err
 	}
 
+	if err := s.sessionService.RevokeAll(ctx, s.db, reset.UserID, 0); err != nil {
+		return err
+	}
+
 	_, err = s.db.NewUpdate().Model(reset).
 		Set("used = ?", true).
 		Where("id = ?", reset.ID).
@@ -221,9 +366,61 @@ func (s *UserService) ResetPassword(ctx context.Context, input ResetPasswordInpu
 		return err
 	}
 
+	if reset.User != nil {
+		s.recordAttempt(ctx, "reset_password", reset.User.Email, ip, true, "")
+		if err := s.throttle.Reset(ctx, ThrottleKey("account", reset.User.Email)); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+func (s *UserService) IsVerified(ctx context.Context, userID int64) (bool, error) {
+	if !s.authConfig.RequireEmailVerification {
+		return true, nil
+	}
+
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return user.Verified, nil
+}
+
+func (s *UserService) PurgeExpiredTokens(ctx context.Context) error {
+	now := time.Now()
+
+	_, err := s.db.NewDelete().
+		Model((*models.EmailVerification)(nil)).
+		WhereOr("expires_at < ?", now).
+		WhereOr("used = ?", true).
+		Exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.NewDelete().
+		Model((*models.PasswordReset)(nil)).
+		WhereOr("expires_at < ?", now).
+		WhereOr("used = ?", true).
+		Exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.NewDelete().
+		Model((*models.EmailChange)(nil)).
+		WhereOr("expires_at < ?", now).
+		WhereOr("used = ?", true).
+		Exec(ctx)
+
+	return err
+}
+
 func (s *UserService) GetByID(ctx context.Context, id int64) (*models.User, error) {
 	user := new(models.User)
 	err := s.db.NewSelect().Model(user).Where("id = ?", id).Scan(ctx)
@@ -232,3 +429,279 @@ func (s *UserService) GetByID(ctx context.Context, id int64) (*models.User, erro
 	}
 	return user, nil
 }
+
+func (s *UserService) UpdateProfile(ctx context.Context, userID int64, input UpdateProfileInput) (*models.User, error) {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if input.FirstName != nil {
+		user.FirstName = *input.FirstName
+	}
+
+	if input.LastName != nil {
+		user.LastName = *input.LastName
+	}
+
+	user.UpdatedAt = time.Now()
+
+	_, err = s.db.NewUpdate().Model(user).
+		Column("first_name", "last_name", "updated_at").
+		Where("id = ?", userID).
+		Exec(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *UserService) ChangePassword(ctx context.Context, userID, sessionID int64, input ChangePasswordInput) error {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if !utils.CheckPasswordHash(input.CurrentPassword, user.Password) {
+		return errors.New("current password is incorrect")
+	}
+
+	hashedPassword, err := utils.HashPassword(input.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().Model((*models.User)(nil)).
+			Set("password = ?", hashedPassword).
+			Set("updated_at = ?", time.Now()).
+			Where("id = ?", userID).
+			Exec(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		return s.sessionService.RevokeAll(ctx, tx, userID, sessionID)
+	})
+}
+
+func (s *UserService) RequestEmailChange(ctx context.Context, userID int64, input ChangeEmailInput, baseURL string) error {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if !utils.CheckPasswordHash(input.Password, user.Password) {
+		return errors.New("password is incorrect")
+	}
+
+	if strings.EqualFold(user.Email, input.NewEmail) {
+		return errors.New("new email is the same as the current one")
+	}
+
+	exists, err := s.db.NewSelect().Model((*models.User)(nil)).Where("email = ?", input.NewEmail).Exists(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("user with this email already exists")
+	}
+
+	_, err = s.db.NewUpdate().Model((*models.EmailChange)(nil)).
+		Set("used = ?", true).
+		Where("user_id = ?", userID).
+		Where("used = ?", false).
+		Exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	token, err := utils.GenerateVerificationToken()
+	if err != nil {
+		return err
+	}
+
+	change := &models.EmailChange{
+		UserID:    userID,
+		NewEmail:  input.NewEmail,
+		Token:     token,
+		ExpiresAt: time.Now().Add(s.authConfig.VerificationTokenTTL),
+	}
+
+	_, err = s.db.NewInsert().Model(change).Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.emailService.SendEmailChangeConfirmation(input.NewEmail, token, baseURL)
+}
+
+func (s *UserService) ConfirmEmailChange(ctx context.Context, token string) error {
+	change := new(models.EmailChange)
+	err := s.db.NewSelect().Model(change).
+		Where("token = ?", token).
+		Where("used = ?", false).
+		Where("expires_at > ?", time.Now()).
+		Scan(ctx)
+
+	if err != nil {
+		return errors.New("invalid or expired token")
+	}
+
+	user, err := s.GetByID(ctx, change.UserID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	previousEmail := user.Email
+
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		exists, err := tx.NewSelect().Model((*models.User)(nil)).Where("email = ?", change.NewEmail).Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errors.New("user with this email already exists")
+		}
+
+		_, err = tx.NewUpdate().Model((*models.User)(nil)).
+			Set("email = ?", change.NewEmail).
+			Set("verified = ?", true).
+			Set("updated_at = ?", time.Now()).
+			Where("id = ?", change.UserID).
+			Exec(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().Model(change).
+			Set("used = ?", true).
+			Where("id = ?", change.ID).
+			Exec(ctx)
+
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.emailService.SendEmailChangedNotice(previousEmail, change.NewEmail); err != nil {
+		log.Printf("Failed to notify user %d of email change: %v", change.UserID, err)
+	}
+
+	return nil
+}
+
+func (s *UserService) DeleteAccount(ctx context.Context, userID int64, input DeleteAccountInput) error {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if !utils.CheckPasswordHash(input.Password, user.Password) {
+		return errors.New("password is incorrect")
+	}
+
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var ownedTeams []models.Team
+		err := tx.NewSelect().Model(&ownedTeams).Where("owner_id = ?", userID).WhereAllWithDeleted().Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, team := range ownedTeams {
+			others, err := tx.NewSelect().
+				Model((*models.TeamMembership)(nil)).
+				Where("team_id = ?", team.ID).
+				Where("user_id != ?", userID).
+				Count(ctx)
+
+			if err != nil {
+				return err
+			}
+
+			// Teams already in the trash go with the account, whoever else was in them.
+			if others > 0 && team.DeletedAt.IsZero() {
+				return fmt.Errorf("you own the team %q which has other members; transfer ownership or delete the team first", team.Name)
+			}
+
+			if err := purgeTeam(ctx, tx, team.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := purgeResources(ctx, tx, "team_id IS NULL AND user_id = ?", userID); err != nil {
+			return err
+		}
+
+		for _, model := range []interface{}{(*models.Subscription)(nil), (*models.Channel)(nil)} {
+			_, err = tx.NewUpdate().
+				Model(model).
+				TableExpr("teams AS t").
+				Set("user_id = t.owner_id").
+				Where("?TableAlias.team_id = t.id").
+				Where("?TableAlias.user_id = ?", userID).
+				WhereAllWithDeleted().
+				Exec(ctx)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, model := range []interface{}{
+			(*models.TeamMembership)(nil),
+			(*models.EmailVerification)(nil),
+			(*models.PasswordReset)(nil),
+			(*models.EmailChange)(nil),
+			(*models.Session)(nil),
+		} {
+			_, err = tx.NewDelete().Model(model).Where("user_id = ?", userID).ForceDelete().Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.NewDelete().Model((*models.User)(nil)).Where("id = ?", userID).ForceDelete().Exec(ctx)
+		return err
+	})
+}
+
+func purgeResources(ctx context.Context, tx bun.Tx, where string, args ...interface{}) error {
+	subscriptionIDs := tx.NewSelect().Model((*models.Subscription)(nil)).Column("id").Where(where, args...).WhereAllWithDeleted()
+	channelIDs := tx.NewSelect().Model((*models.Channel)(nil)).Column("id").Where(where, args...).WhereAllWithDeleted()
+
+	_, err := tx.NewDelete().
+		Model((*models.Notification)(nil)).
+		WhereOr("subscription_id IN (?)", subscriptionIDs).
+		WhereOr("channel_id IN (?)", channelIDs).
+		Exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NewDelete().
+		Model((*models.SubscriptionChannel)(nil)).
+		WhereOr("subscription_id IN (?)", subscriptionIDs).
+		WhereOr("channel_id IN (?)", channelIDs).
+		ForceDelete().
+		Exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NewDelete().Model((*models.Subscription)(nil)).Where(where, args...).ForceDelete().Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NewDelete().Model((*models.Channel)(nil)).Where(where, args...).ForceDelete().Exec(ctx)
+	return err
+}
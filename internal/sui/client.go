@@ -0,0 +1,118 @@
+package sui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/open-move/intercord/internal/config"
+)
+
+// Client talks to a Sui full node over JSON-RPC.
+type Client struct {
+	url        string
+	httpClient *http.Client
+	nextID     atomic.Int64
+}
+
+func NewClient(cfg *config.SuiConfig) *Client {
+	return &Client{
+		url:        cfg.RPCURL,
+		httpClient: &http.Client{Timeout: cfg.RequestTimeout},
+	}
+}
+
+// RPCError is an error returned by the node itself, as opposed to a failure to
+// reach it. Lookups of objects, packages or modules that don't exist fail
+// with an RPCError.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("sui rpc error %d: %s", e.Code, e.Message)
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int64         `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// Call invokes a JSON-RPC method and decodes its result into result.
+func (c *Client) Call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      c.nextID.Add(1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %s", method, resp.Status)
+	}
+
+	var response rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("%s: decoding response: %w", method, err)
+	}
+
+	if response.Error != nil {
+		return response.Error
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(response.Result, result)
+}
+
+// GetNormalizedMoveModulesByPackage fetches every module of a published
+// package, keyed by module name.
+func (c *Client) GetNormalizedMoveModulesByPackage(ctx context.Context, pkg string) (map[string]NormalizedModule, error) {
+	var result map[string]NormalizedModule
+	if err := c.Call(ctx, "sui_getNormalizedMoveModulesByPackage", &result, pkg); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetNormalizedMoveModule fetches the structs and functions of a published
+// module.
+func (c *Client) GetNormalizedMoveModule(ctx context.Context, pkg, module string) (*NormalizedModule, error) {
+	result := new(NormalizedModule)
+	if err := c.Call(ctx, "sui_getNormalizedMoveModule", result, pkg, module); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,36 @@
+package sui
+
+import "context"
+
+type Balance struct {
+	CoinType        string `json:"coinType"`
+	CoinObjectCount int    `json:"coinObjectCount"`
+	TotalBalance    string `json:"totalBalance"`
+}
+
+type CoinMetadata struct {
+	Decimals    int    `json:"decimals"`
+	Name        string `json:"name"`
+	Symbol      string `json:"symbol"`
+	Description string `json:"description"`
+	IconURL     string `json:"iconUrl,omitempty"`
+}
+
+// GetBalance returns an address's total balance of a coin type, in the
+// coin's smallest unit.
+func (c *Client) GetBalance(ctx context.Context, owner, coinType string) (*Balance, error) {
+	result := new(Balance)
+	if err := c.Call(ctx, "suix_getBalance", result, owner, coinType); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetCoinMetadata returns a coin type's metadata, or nil if it has none.
+func (c *Client) GetCoinMetadata(ctx context.Context, coinType string) (*CoinMetadata, error) {
+	var result *CoinMetadata
+	if err := c.Call(ctx, "suix_getCoinMetadata", &result, coinType); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
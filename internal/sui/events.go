@@ -0,0 +1,73 @@
+package sui
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+type EventID struct {
+	TxDigest string `json:"txDigest"`
+	EventSeq string `json:"eventSeq"`
+}
+
+type Event struct {
+	ID                EventID         `json:"id"`
+	PackageID         string          `json:"packageId"`
+	TransactionModule string          `json:"transactionModule"`
+	Sender            string          `json:"sender"`
+	Type              string          `json:"type"`
+	ParsedJSON        json.RawMessage `json:"parsedJson"`
+	TimestampMs       string          `json:"timestampMs,omitempty"`
+}
+
+// Timestamp is when the checkpoint holding the event was created, or the zero
+// time when the node didn't say.
+func (e Event) Timestamp() time.Time {
+	ms, err := strconv.ParseInt(e.TimestampMs, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+type EventPage struct {
+	Data        []Event  `json:"data"`
+	NextCursor  *EventID `json:"nextCursor"`
+	HasNextPage bool     `json:"hasNextPage"`
+}
+
+// EventFilter is the JSON form of a Sui event filter, such as
+// {"MoveEventModule": {"package": "0x2", "module": "coin"}}.
+type EventFilter map[string]interface{}
+
+func SenderEvents(sender string) EventFilter {
+	return EventFilter{"Sender": sender}
+}
+
+func ModuleEvents(pkg, module string) EventFilter {
+	return EventFilter{"MoveEventModule": map[string]string{"package": pkg, "module": module}}
+}
+
+func EventTypeEvents(eventType string) EventFilter {
+	return EventFilter{"MoveEventType": eventType}
+}
+
+// QueryEvents pages through the events matching filter, starting after cursor.
+func (c *Client) QueryEvents(ctx context.Context, filter EventFilter, cursor *EventID, limit int, descending bool) (*EventPage, error) {
+	result := new(EventPage)
+	if err := c.Call(ctx, "suix_queryEvents", result, filter, cursor, limit, descending); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetEvents returns every event a transaction emitted.
+func (c *Client) GetEvents(ctx context.Context, digest string) ([]Event, error) {
+	var result []Event
+	if err := c.Call(ctx, "sui_getEvents", &result, digest); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,135 @@
+package sui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/open-move/intercord/internal/move"
+)
+
+type NormalizedModule struct {
+	Address string                      `json:"address"`
+	Name    string                      `json:"name"`
+	Structs map[string]NormalizedStruct `json:"structs"`
+}
+
+type NormalizedStruct struct {
+	Abilities      AbilitySet                `json:"abilities"`
+	TypeParameters []NormalizedTypeParameter `json:"typeParameters"`
+	Fields         []NormalizedField         `json:"fields"`
+}
+
+type AbilitySet struct {
+	Abilities []string `json:"abilities"`
+}
+
+// Has reports whether the set includes an ability, named as Move spells it
+// ("copy", "drop", "store" or "key").
+func (s AbilitySet) Has(ability string) bool {
+	for _, a := range s.Abilities {
+		if strings.EqualFold(a, ability) {
+			return true
+		}
+	}
+	return false
+}
+
+type NormalizedTypeParameter struct {
+	Constraints AbilitySet `json:"constraints"`
+	IsPhantom   bool       `json:"isPhantom"`
+}
+
+type NormalizedField struct {
+	Name string         `json:"name"`
+	Type NormalizedType `json:"type"`
+}
+
+type NormalizedStructRef struct {
+	Address       string           `json:"address"`
+	Module        string           `json:"module"`
+	Name          string           `json:"name"`
+	TypeArguments []NormalizedType `json:"typeArguments"`
+}
+
+// NormalizedType is the node's representation of a Move type. Primitives come
+// as bare strings ("U64"), everything else as a single-key object.
+type NormalizedType struct {
+	Primitive        string
+	Struct           *NormalizedStructRef
+	Vector           *NormalizedType
+	TypeParameter    *int
+	Reference        *NormalizedType
+	MutableReference *NormalizedType
+}
+
+func (t *NormalizedType) UnmarshalJSON(data []byte) error {
+	var primitive string
+	if err := json.Unmarshal(data, &primitive); err == nil {
+		t.Primitive = strings.ToLower(primitive)
+		return nil
+	}
+
+	var composite struct {
+		Struct           *NormalizedStructRef `json:"Struct"`
+		Vector           *NormalizedType      `json:"Vector"`
+		TypeParameter    *int                 `json:"TypeParameter"`
+		Reference        *NormalizedType      `json:"Reference"`
+		MutableReference *NormalizedType      `json:"MutableReference"`
+	}
+	if err := json.Unmarshal(data, &composite); err != nil {
+		return err
+	}
+
+	if composite.Struct == nil && composite.Vector == nil && composite.TypeParameter == nil &&
+		composite.Reference == nil && composite.MutableReference == nil {
+		return fmt.Errorf("unknown normalized type %s", data)
+	}
+
+	t.Struct = composite.Struct
+	t.Vector = composite.Vector
+	t.TypeParameter = composite.TypeParameter
+	t.Reference = composite.Reference
+	t.MutableReference = composite.MutableReference
+	return nil
+}
+
+// Render writes the type in the same canonical form as move.TypeTag,
+// substituting type parameters with args. Parameters without an argument, or
+// with an empty one, are written as T0, T1 and so on.
+func (t NormalizedType) Render(args []string) string {
+	switch {
+	case t.Struct != nil:
+		var b strings.Builder
+		address, err := move.NormalizeAddress(t.Struct.Address)
+		if err != nil {
+			address = t.Struct.Address
+		}
+		b.WriteString(address + "::" + t.Struct.Module + "::" + t.Struct.Name)
+
+		if len(t.Struct.TypeArguments) > 0 {
+			b.WriteString("<")
+			for i, arg := range t.Struct.TypeArguments {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				b.WriteString(arg.Render(args))
+			}
+			b.WriteString(">")
+		}
+		return b.String()
+	case t.Vector != nil:
+		return "vector<" + t.Vector.Render(args) + ">"
+	case t.TypeParameter != nil:
+		if *t.TypeParameter < len(args) && args[*t.TypeParameter] != "" {
+			return args[*t.TypeParameter]
+		}
+		return fmt.Sprintf("T%d", *t.TypeParameter)
+	case t.Reference != nil:
+		return "&" + t.Reference.Render(args)
+	case t.MutableReference != nil:
+		return "&mut " + t.MutableReference.Render(args)
+	default:
+		return t.Primitive
+	}
+}
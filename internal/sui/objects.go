@@ -0,0 +1,134 @@
+package sui
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// ObjectData is an object as returned by sui_getObject. Which fields are set
+// depends on the options asked for.
+type ObjectData struct {
+	ObjectID            string          `json:"objectId"`
+	Version             string          `json:"version"`
+	Digest              string          `json:"digest"`
+	Type                string          `json:"type,omitempty"`
+	Owner               *Owner          `json:"owner,omitempty"`
+	PreviousTransaction string          `json:"previousTransaction,omitempty"`
+	Content             json.RawMessage `json:"content,omitempty"`
+	Bcs                 json.RawMessage `json:"bcs,omitempty"`
+}
+
+type objectResponse struct {
+	Data  *ObjectData     `json:"data"`
+	Error json.RawMessage `json:"error"`
+}
+
+type pastObject struct {
+	Status  string          `json:"status"`
+	Details json.RawMessage `json:"details"`
+}
+
+// GetObject returns the current version of an object, or nil if it doesn't
+// exist or was deleted.
+func (c *Client) GetObject(ctx context.Context, objectID string, options map[string]bool) (*ObjectData, error) {
+	result := new(objectResponse)
+	if err := c.Call(ctx, "sui_getObject", result, objectID, options); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// GetPastObject returns an object at a version, or nil when the node no longer
+// has that version.
+func (c *Client) GetPastObject(ctx context.Context, objectID, version string, options map[string]bool) (*ObjectData, error) {
+	sequence, err := strconv.ParseUint(version, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(pastObject)
+	if err := c.Call(ctx, "sui_tryGetPastObject", result, objectID, sequence, options); err != nil {
+		return nil, err
+	}
+
+	if result.Status != "VersionFound" {
+		return nil, nil
+	}
+
+	object := new(ObjectData)
+	if err := json.Unmarshal(result.Details, object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// GetPastObjectOwner returns who owned an object at a version, or nil when the
+// node no longer has that version.
+func (c *Client) GetPastObjectOwner(ctx context.Context, objectID, version string) (*Owner, error) {
+	object, err := c.GetPastObject(ctx, objectID, version, map[string]bool{"showOwner": true})
+	if err != nil || object == nil {
+		return nil, err
+	}
+	return object.Owner, nil
+}
+
+// UpgradeCap is the content of a 0x2::package::UpgradeCap: the latest version
+// of the package it upgrades and the upgrade policy.
+type UpgradeCap struct {
+	Package string      `json:"package"`
+	Version json.Number `json:"version"`
+	Policy  json.Number `json:"policy"`
+}
+
+// UpgradeCapType is the type of the objects that authorize package upgrades.
+const UpgradeCapType = "0x2::package::UpgradeCap"
+
+// Upgrade policies, from most to least permissive.
+var UpgradePolicies = map[string]string{
+	"0":   "compatible",
+	"128": "additive",
+	"192": "dep_only",
+}
+
+// UpgradeCap decodes an object fetched with showContent as an UpgradeCap.
+func (o *ObjectData) UpgradeCap() (*UpgradeCap, error) {
+	var content struct {
+		Fields UpgradeCap `json:"fields"`
+	}
+	if err := json.Unmarshal(o.Content, &content); err != nil {
+		return nil, fmt.Errorf("decoding upgrade cap: %w", err)
+	}
+	return &content.Fields, nil
+}
+
+// GetPackageModules returns the bytecode of each module of a package.
+func (c *Client) GetPackageModules(ctx context.Context, pkg string) (map[string][]byte, error) {
+	object, err := c.GetObject(ctx, pkg, map[string]bool{"showBcs": true})
+	if err != nil {
+		return nil, err
+	}
+	if object == nil {
+		return nil, fmt.Errorf("package %s not found", pkg)
+	}
+
+	var bcs struct {
+		ModuleMap map[string]string `json:"moduleMap"`
+	}
+	if err := json.Unmarshal(object.Bcs, &bcs); err != nil {
+		return nil, fmt.Errorf("decoding package %s: %w", pkg, err)
+	}
+
+	modules := make(map[string][]byte, len(bcs.ModuleMap))
+	for name, encoded := range bcs.ModuleMap {
+		bytecode, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decoding module %s: %w", name, err)
+		}
+		modules[name] = bytecode
+	}
+
+	return modules, nil
+}
@@ -0,0 +1,39 @@
+package sui
+
+import "context"
+
+// System event types emitted by the 0x3 package.
+const (
+	SystemEpochInfoEventType  = "0x3::sui_system_state_inner::SystemEpochInfoEvent"
+	StakingRequestEventType   = "0x3::validator::StakingRequestEvent"
+	UnstakingRequestEventType = "0x3::validator::UnstakingRequestEvent"
+	ValidatorJoinEventType    = "0x3::validator_set::ValidatorJoinEvent"
+	ValidatorLeaveEventType   = "0x3::validator_set::ValidatorLeaveEvent"
+)
+
+// SystemState is the part of the Sui system state read by intercord.
+type SystemState struct {
+	Epoch                 string             `json:"epoch"`
+	ProtocolVersion       string             `json:"protocolVersion"`
+	ReferenceGasPrice     string             `json:"referenceGasPrice"`
+	EpochStartTimestampMs string             `json:"epochStartTimestampMs"`
+	TotalStake            string             `json:"totalStake"`
+	ActiveValidators      []ValidatorSummary `json:"activeValidators"`
+}
+
+type ValidatorSummary struct {
+	SuiAddress    string `json:"suiAddress"`
+	Name          string `json:"name"`
+	StakingPoolID string `json:"stakingPoolId"`
+	GasPrice      string `json:"gasPrice"`
+	VotingPower   string `json:"votingPower"`
+}
+
+// GetLatestSuiSystemState returns the current epoch and validator set.
+func (c *Client) GetLatestSuiSystemState(ctx context.Context) (*SystemState, error) {
+	result := new(SystemState)
+	if err := c.Call(ctx, "suix_getLatestSuiSystemState", result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,275 @@
+package sui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Transaction kinds as reported in a transaction block's input.
+const (
+	TransactionKindProgrammable        = "ProgrammableTransaction"
+	TransactionKindChangeEpoch         = "ChangeEpoch"
+	TransactionKindGenesis             = "Genesis"
+	TransactionKindConsensusCommit     = "ConsensusCommitPrologue"
+	TransactionKindConsensusCommitV2   = "ConsensusCommitPrologueV2"
+	TransactionKindConsensusCommitV3   = "ConsensusCommitPrologueV3"
+	TransactionKindAuthenticatorUpdate = "AuthenticatorStateUpdate"
+	TransactionKindRandomnessUpdate    = "RandomnessStateUpdate"
+	TransactionKindEndOfEpoch          = "EndOfEpochTransaction"
+)
+
+var TransactionKinds = []string{
+	TransactionKindProgrammable,
+	TransactionKindChangeEpoch,
+	TransactionKindGenesis,
+	TransactionKindConsensusCommit,
+	TransactionKindConsensusCommitV2,
+	TransactionKindConsensusCommitV3,
+	TransactionKindAuthenticatorUpdate,
+	TransactionKindRandomnessUpdate,
+	TransactionKindEndOfEpoch,
+}
+
+type TransactionBlock struct {
+	Digest         string              `json:"digest"`
+	Transaction    *TransactionInput   `json:"transaction,omitempty"`
+	Effects        *TransactionEffects `json:"effects,omitempty"`
+	BalanceChanges []BalanceChange     `json:"balanceChanges,omitempty"`
+	ObjectChanges  []ObjectChange      `json:"objectChanges,omitempty"`
+	TimestampMs    string              `json:"timestampMs,omitempty"`
+	Checkpoint     string              `json:"checkpoint,omitempty"`
+}
+
+type TransactionInput struct {
+	Data struct {
+		Sender      string `json:"sender"`
+		Transaction struct {
+			Kind string `json:"kind"`
+		} `json:"transaction"`
+	} `json:"data"`
+}
+
+type TransactionEffects struct {
+	Status struct {
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+	} `json:"status"`
+	GasUsed            GasCostSummary      `json:"gasUsed"`
+	ModifiedAtVersions []ModifiedAtVersion `json:"modifiedAtVersions,omitempty"`
+}
+
+// ModifiedAtVersion is the version an object had before a transaction changed
+// or deleted it.
+type ModifiedAtVersion struct {
+	ObjectID       string `json:"objectId"`
+	SequenceNumber string `json:"sequenceNumber"`
+}
+
+type GasCostSummary struct {
+	ComputationCost         string `json:"computationCost"`
+	StorageCost             string `json:"storageCost"`
+	StorageRebate           string `json:"storageRebate"`
+	NonRefundableStorageFee string `json:"nonRefundableStorageFee"`
+}
+
+type BalanceChange struct {
+	Owner    Owner  `json:"owner"`
+	CoinType string `json:"coinType"`
+	Amount   string `json:"amount"`
+}
+
+// Object change types.
+const (
+	ObjectCreated     = "created"
+	ObjectMutated     = "mutated"
+	ObjectTransferred = "transferred"
+	ObjectWrapped     = "wrapped"
+	ObjectDeleted     = "deleted"
+	ObjectPublished   = "published"
+)
+
+// ObjectChange is one entry of a transaction's objectChanges. Which fields are
+// set depends on Type: transfers have a Recipient, publishes a PackageID and
+// Modules, and deleted or wrapped objects no owner.
+type ObjectChange struct {
+	Type            string   `json:"type"`
+	Sender          string   `json:"sender,omitempty"`
+	Owner           *Owner   `json:"owner,omitempty"`
+	Recipient       *Owner   `json:"recipient,omitempty"`
+	ObjectType      string   `json:"objectType,omitempty"`
+	ObjectID        string   `json:"objectId,omitempty"`
+	Version         string   `json:"version,omitempty"`
+	PreviousVersion string   `json:"previousVersion,omitempty"`
+	Digest          string   `json:"digest,omitempty"`
+	PackageID       string   `json:"packageId,omitempty"`
+	Modules         []string `json:"modules,omitempty"`
+}
+
+// Owner kinds.
+const (
+	OwnerAddress   = "address"
+	OwnerObject    = "object"
+	OwnerShared    = "shared"
+	OwnerImmutable = "immutable"
+)
+
+// Owner is who owns an object or balance. Address is set for address and
+// object owners.
+type Owner struct {
+	Kind    string
+	Address string
+}
+
+func (o *Owner) UnmarshalJSON(data []byte) error {
+	var immutable string
+	if err := json.Unmarshal(data, &immutable); err == nil {
+		o.Kind = OwnerImmutable
+		return nil
+	}
+
+	var owner struct {
+		AddressOwner          string          `json:"AddressOwner"`
+		ObjectOwner           string          `json:"ObjectOwner"`
+		Shared                json.RawMessage `json:"Shared"`
+		ConsensusAddressOwner *struct {
+			Owner string `json:"owner"`
+		} `json:"ConsensusAddressOwner"`
+	}
+	if err := json.Unmarshal(data, &owner); err != nil {
+		return err
+	}
+
+	switch {
+	case owner.AddressOwner != "":
+		o.Kind, o.Address = OwnerAddress, owner.AddressOwner
+	case owner.ConsensusAddressOwner != nil:
+		o.Kind, o.Address = OwnerAddress, owner.ConsensusAddressOwner.Owner
+	case owner.ObjectOwner != "":
+		o.Kind, o.Address = OwnerObject, owner.ObjectOwner
+	case owner.Shared != nil:
+		o.Kind = OwnerShared
+	default:
+		return fmt.Errorf("unknown owner %s", data)
+	}
+	return nil
+}
+
+func (o Owner) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"kind": o.Kind, "address": o.Address})
+}
+
+// Kind returns the transaction's kind, or "" if the input wasn't requested.
+func (t TransactionBlock) Kind() string {
+	if t.Transaction == nil {
+		return ""
+	}
+	return t.Transaction.Data.Transaction.Kind
+}
+
+func (t TransactionBlock) Sender() string {
+	if t.Transaction == nil {
+		return ""
+	}
+	return t.Transaction.Data.Sender
+}
+
+func (t TransactionBlock) Timestamp() time.Time {
+	ms, err := strconv.ParseInt(t.TimestampMs, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// TransactionFilter is the JSON form of a Sui transaction filter, such as
+// {"FromAddress": "0x..."}.
+type TransactionFilter map[string]interface{}
+
+func FromAddress(address string) TransactionFilter {
+	return TransactionFilter{"FromAddress": address}
+}
+
+func ToAddress(address string) TransactionFilter {
+	return TransactionFilter{"ToAddress": address}
+}
+
+func FromOrToAddress(address string) TransactionFilter {
+	return TransactionFilter{"FromOrToAddress": map[string]string{"addr": address}}
+}
+
+// AffectedObject matches transactions that created, mutated, wrapped,
+// unwrapped or deleted an object.
+func AffectedObject(objectID string) TransactionFilter {
+	return TransactionFilter{"AffectedObject": objectID}
+}
+
+// MoveFunction matches transactions calling into a package.
+func MoveFunction(pkg string) TransactionFilter {
+	return TransactionFilter{"MoveFunction": map[string]string{"package": pkg}}
+}
+
+type TransactionPage struct {
+	Data        []TransactionBlock `json:"data"`
+	NextCursor  *string            `json:"nextCursor"`
+	HasNextPage bool               `json:"hasNextPage"`
+}
+
+// fullTransaction asks for everything a notification may describe.
+var fullTransaction = map[string]bool{
+	"showInput":          true,
+	"showEffects":        true,
+	"showBalanceChanges": true,
+	"showObjectChanges":  true,
+}
+
+// QueryTransactionBlocks pages through the transactions matching filter,
+// starting after the cursor digest, with their effects and changes.
+func (c *Client) QueryTransactionBlocks(ctx context.Context, filter TransactionFilter, cursor *string, limit int, descending bool) (*TransactionPage, error) {
+	query := map[string]interface{}{
+		"filter":  filter,
+		"options": fullTransaction,
+	}
+
+	result := new(TransactionPage)
+	if err := c.Call(ctx, "suix_queryTransactionBlocks", result, query, cursor, limit, descending); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetTransactionBlock fetches one transaction with its effects and changes.
+func (c *Client) GetTransactionBlock(ctx context.Context, digest string) (*TransactionBlock, error) {
+	result := new(TransactionBlock)
+	if err := c.Call(ctx, "sui_getTransactionBlock", result, digest, fullTransaction); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// maxMultiGet is the most digests the node accepts in one
+// sui_multiGetTransactionBlocks call.
+const maxMultiGet = 50
+
+// GetTransactionBlocks fetches transactions with their inputs, in batches the
+// node accepts.
+func (c *Client) GetTransactionBlocks(ctx context.Context, digests []string) ([]TransactionBlock, error) {
+	var blocks []TransactionBlock
+	for start := 0; start < len(digests); start += maxMultiGet {
+		end := start + maxMultiGet
+		if end > len(digests) {
+			end = len(digests)
+		}
+
+		var batch []TransactionBlock
+		options := map[string]bool{"showInput": true}
+		if err := c.Call(ctx, "sui_multiGetTransactionBlocks", &batch, digests[start:end], options); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, batch...)
+	}
+
+	return blocks, nil
+}
@@ -8,14 +8,16 @@ import (
 )
 
 type Claims struct {
-	UserID int64 `json:"user_id"`
+	UserID    int64 `json:"user_id"`
+	SessionID int64 `json:"session_id"`
 	jwt.RegisteredClaims
 }
 
-func GenerateAccessToken(userID int64, config *config.JWTConfig) (string, error) {
+func GenerateAccessToken(userID, sessionID int64, config *config.JWTConfig) (string, error) {
 	expirationTime := time.Now().Add(config.AccessTokenTTL)
 	claims := &Claims{
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -27,10 +29,11 @@ func GenerateAccessToken(userID int64, config *config.JWTConfig) (string, error)
 	return token.SignedString([]byte(config.Secret))
 }
 
-func GenerateRefreshToken(userID int64, config *config.JWTConfig) (string, error) {
+func GenerateRefreshToken(userID, sessionID int64, config *config.JWTConfig) (string, error) {
 	expirationTime := time.Now().Add(config.RefreshTokenTTL)
 	claims := &Claims{
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+func Sign(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func VerifySignature(secret, payload, signature string) bool {
+	expected := Sign(secret, payload)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func SignToken(secret, scope, value string) string {
+	return value + "." + Sign(secret, scope+":"+value)
+}
+
+func ParseSignedToken(secret, scope, token string) (string, bool) {
+	separator := strings.LastIndex(token, ".")
+	if separator <= 0 {
+		return "", false
+	}
+
+	value, signature := token[:separator], token[separator+1:]
+	if !VerifySignature(secret, scope+":"+value, signature) {
+		return "", false
+	}
+
+	return value, true
+}